@@ -0,0 +1,125 @@
+// Commands working over already created projects.
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"source-template/pkg/pkgcheck"
+	"source-template/pkg/symbols"
+)
+
+// commands holds every supported command, chosen by the first command line
+// argument. They work over already created projects instead of creating new
+// ones.
+var commands = map[string]func(args []string) error{
+	"sync-symbols":  syncSymbols,
+	"check-package": checkPackage,
+}
+
+// syncSymbols updates a library version script with the __PUB_API__ functions
+// found inside its sources.
+func syncSymbols(args []string) error {
+	var dryRun bool
+	flags := flag.NewFlagSet("sync-symbols", flag.ExitOnError)
+
+	flags.BoolVar(&dryRun, "n", false,
+		"Only shows the changes, without updating the version script.")
+
+	flags.Usage = func() {
+		fmt.Printf("Usage: %s sync-symbols [OPTIONS] [LIBRARY_PATH]\n", AppName)
+		fmt.Print("Updates misc/lib<name>.sym with the library public API.\n\n")
+		fmt.Println("Options:")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+	path := "."
+
+	if flags.NArg() > 0 {
+		path = flags.Arg(0)
+	}
+
+	report, err := symbols.Sync(path, !dryRun)
+
+	if err != nil {
+		return err
+	}
+
+	for _, symbol := range report.Removed {
+		fmt.Printf("warning: %s is exported but not found in the sources anymore, "+
+			"removing it breaks the library ABI\n", symbol)
+	}
+
+	if len(report.Added) == 0 {
+		fmt.Printf("%s is up to date\n", report.Filename)
+		return nil
+	}
+
+	if report.NewNode {
+		fmt.Printf("New version node %s\n", report.Node)
+	}
+
+	for _, symbol := range report.Added {
+		fmt.Printf("Adding %s to %s\n", symbol, report.Node)
+	}
+
+	return nil
+}
+
+// checkPackage inspects a package project, reporting the mistakes which would
+// only show up while building its packages.
+func checkPackage(args []string) error {
+	flags := flag.NewFlagSet("check-package", flag.ExitOnError)
+
+	flags.Usage = func() {
+		fmt.Printf("Usage: %s check-package [PACKAGE_PATH]\n", AppName)
+		fmt.Print("Inspects a package-<name> project without building it.\n")
+	}
+
+	flags.Parse(args)
+	path := "."
+
+	if flags.NArg() > 0 {
+		path = flags.Arg(0)
+	}
+
+	report, err := pkgcheck.Check(path)
+
+	if err != nil {
+		return err
+	}
+
+	for _, finding := range report.Findings {
+		if finding.Warning {
+			fmt.Printf("%s: warning: %s\n", finding.Filename, finding.Message)
+		} else {
+			fmt.Printf("%s: %s\n", finding.Filename, finding.Message)
+		}
+	}
+
+	if problems := report.Problems(); problems > 0 {
+		return fmt.Errorf("%d problem(s) found", problems)
+	}
+
+	fmt.Println("no problems found")
+
+	return nil
+}
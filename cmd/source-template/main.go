@@ -24,6 +24,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
 
 	"source-template/pkg/base"
 	"source-template/pkg/project"
@@ -39,7 +42,7 @@ type CLIOptions struct {
 
 func validateProjectType(projectType int) error {
 	switch {
-	case projectType >= base.SingleSourceProject && projectType <= base.XantePluginProject:
+	case projectType >= base.SingleSourceProject && projectType <= base.ScriptProject:
 		return nil
 	}
 
@@ -47,6 +50,15 @@ func validateProjectType(projectType int) error {
 }
 
 func validateProjectLanguage(language int, projectType int) error {
+	// Scripts are always written in bash, so no other language may be chosen
+	if projectType == base.ScriptProject {
+		if language != base.CLanguage {
+			return errors.New("Script projects are always written in bash, -language can't be used")
+		}
+
+		return nil
+	}
+
 	if language != base.CLanguage && projectType != base.XantePluginProject {
 		return errors.New("Programming language unsupported for this kind of project")
 	}
@@ -59,6 +71,38 @@ func validateProjectLanguage(language int, projectType int) error {
 	return errors.New("Unsupported programming language")
 }
 
+func validateBuildSystem(buildSystem int, language int, projectType int) error {
+	// Scripts are installed as they are, without any build system
+	if projectType == base.ScriptProject {
+		if buildSystem != base.CMakeBuildSystem {
+			return errors.New("Script projects have no build system, -build-system can't be used")
+		}
+
+		return nil
+	}
+
+	if buildSystem != base.CMakeBuildSystem && language != base.CLanguage {
+		return errors.New("Build system unsupported for this programming language")
+	}
+
+	switch {
+	case buildSystem >= base.CMakeBuildSystem && buildSystem <= base.MakeBuildSystem:
+		return nil
+	}
+
+	return errors.New("Unsupported build system")
+}
+
+// validateServiceType checks the packaged service type is one systemd knows.
+func validateServiceType(serviceType string) error {
+	switch serviceType {
+	case "simple", "notify", "forking":
+		return nil
+	}
+
+	return errors.New("Unsupported systemd service type")
+}
+
 // validateOptions does the command line options validations
 func validateOptions(options CLIOptions) error {
 	if options.AuthorName == "" {
@@ -81,18 +125,84 @@ func validateOptions(options CLIOptions) error {
 		return err
 	}
 
+	err = validateBuildSystem(options.BuildSystem, options.Language,
+		options.ProjectType)
+
+	if err != nil {
+		return err
+	}
+
+	err = validateServiceType(options.ServiceType)
+
+	if err != nil {
+		return err
+	}
+
+	if options.ProjectType == base.ScriptProject && options.ServiceTimer == "" &&
+		(options.ServiceUser != "" || options.ServiceHardening ||
+			options.ServiceEnvironment || options.ServiceSocket != "") {
+		return errors.New("Scripts only get a service when run by a timer, use -service-timer")
+	}
+
+	if options.Config &&
+		(!options.PackageProject || options.ProjectType != base.ApplicationProject) {
+		return errors.New("Configuration files are only created for packaged applications")
+	}
+
+	if options.LogRotate && (!options.HasService() ||
+		options.ProjectType != base.ApplicationProject || options.ServiceTimer != "") {
+		return errors.New("Log rotation is only used by long-running packaged applications")
+	}
+
+	if options.Container && options.ProjectType != base.ApplicationProject {
+		return errors.New("Container images are only created for applications")
+	}
+
+	if len(options.PackageFormats) > 0 && !options.PackageProject {
+		return errors.New("Package formats are only used by package projects")
+	}
+
+	if !filepath.IsAbs(options.InstallPrefix) {
+		return errors.New("The installation prefix must be an absolute path")
+	}
+
 	return nil
 }
 
+// defaultEmail gives the same email address debian tools use when none is
+// configured.
+func defaultEmail() string {
+	if email := os.Getenv("DEBEMAIL"); email != "" {
+		return email
+	}
+
+	username := "root"
+
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	hostname, err := os.Hostname()
+
+	if err != nil {
+		hostname = "localhost"
+	}
+
+	return username + "@" + hostname
+}
+
 // TODO: Add description of options
 // getCLIOptions configures the application supported command line options.
 func getCLIOptions() CLIOptions {
 	var options CLIOptions
-	var projectType, language string
+	var projectType, language, buildSystem, style, packageFormats string
 
 	flag.BoolVar(&options.LibcollectionsFeatures, "c", false,
 		"Turn on the use of libcollections features into the templates.")
 
+	flag.BoolVar(&options.CmockaTests, "cmocka", false,
+		"Uses cmocka in the generated unit tests instead of a built-in assertion header.")
+
 	flag.BoolVar(&options.version, "v", false,
 		"Shows the current application version.")
 
@@ -115,6 +225,9 @@ func getCLIOptions() CLIOptions {
 	flag.StringVar(&options.AuthorName, "author", "",
 		"Assigns the project author's name.")
 
+	flag.StringVar(&options.Email, "email", defaultEmail(),
+		"Assigns the project author's email, used by package projects.")
+
 	defaultProject, err := base.ProjectKey(base.SingleSourceProject)
 
 	if err != nil {
@@ -125,9 +238,63 @@ func getCLIOptions() CLIOptions {
 	flag.StringVar(&projectType, "type", defaultProject,
 		"Chooses the template project type.")
 
+	defaultBuildSystem, err := base.BuildSystemKey(base.CMakeBuildSystem)
+
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	flag.StringVar(&buildSystem, "build-system", defaultBuildSystem,
+		"Chooses the build system used by C projects.")
+
+	defaultStyle, err := base.StyleKey(base.ProjectStyle)
+
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	flag.StringVar(&style, "style", defaultStyle,
+		"Chooses the code style of the generated C sources.")
+
+	flag.StringVar(&options.InstallPrefix, "prefix", "/usr/local",
+		"Assigns the default installation prefix of the generated build files.")
+
+	flag.BoolVar(&options.Config, "config", false,
+		"Creates a default configuration file for packaged applications.")
+
+	flag.BoolVar(&options.LogRotate, "logrotate", false,
+		"Writes the packaged application service log into a rotated file.")
+
+	flag.BoolVar(&options.Container, "container", false,
+		"Creates a Containerfile building an application image.")
+
+	flag.StringVar(&packageFormats, "package-format", "",
+		"Also creates the package in the given comma separated formats (arch, alpine).")
+
+	flag.StringVar(&options.ServiceUser, "service-user", "",
+		"Runs the packaged service as a dedicated system user.")
+
+	flag.StringVar(&options.ServiceType, "service-type", "simple",
+		"Chooses the packaged service type (simple, notify or forking).")
+
+	flag.BoolVar(&options.ServiceHardening, "service-hardening", false,
+		"Adds sandboxing directives to the packaged service.")
+
+	flag.BoolVar(&options.ServiceEnvironment, "service-env", false,
+		"Loads the packaged service environment from /etc/default/<name>.")
+
+	flag.StringVar(&options.ServiceSocket, "service-socket", "",
+		"Adds a socket unit listening at the given address to the packaged service.")
+
+	flag.StringVar(&options.ServiceTimer, "service-timer", "",
+		"Adds a timer unit running the packaged service at the given calendar event.")
+
 	flag.Usage = func() {
 		fmt.Printf("Usage: %s [OPTIONS]\n", AppName)
-		fmt.Println("An application to create project templates.\n")
+		fmt.Printf("       %s COMMAND [OPTIONS]\n", AppName)
+		fmt.Print("An application to create project templates.\n\n")
 		fmt.Println("Options:")
 		flag.PrintDefaults()
 		fmt.Println()
@@ -137,12 +304,40 @@ func getCLIOptions() CLIOptions {
   * application
   * library
   * xante-plugin
-`)
+  * script`)
+		fmt.Println()
 
 		fmt.Printf(`Supported xante-plugin languages:
   * C
   * go
 
+`)
+
+		fmt.Printf(`Supported build systems:
+  * cmake
+  * meson
+  * make
+
+`)
+
+		fmt.Printf(`Supported package formats:
+  * arch
+  * alpine
+
+`)
+
+		fmt.Printf(`Supported code styles:
+  * project
+  * linux
+  * gnu
+  * llvm
+
+`)
+
+		fmt.Printf(`Commands:
+  * sync-symbols	Updates a library version script with its public API.
+  * check-package	Inspects a package project before building it.
+
 `)
 	}
 
@@ -167,6 +362,35 @@ func getCLIOptions() CLIOptions {
 		os.Exit(-1)
 	}
 
+	options.BuildSystem, err = base.BuildSystemLookup(buildSystem)
+
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	options.Style, err = base.StyleLookup(style)
+
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	if packageFormats != "" {
+		for _, f := range strings.Split(packageFormats, ",") {
+			format, err := base.PackageFormatLookup(strings.TrimSpace(f))
+
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+
+			options.PackageFormats = append(options.PackageFormats, format)
+		}
+	}
+
+	options.InstallPrefix = filepath.Clean(options.InstallPrefix)
+
 	if err := validateOptions(options); err != nil {
 		fmt.Println(err)
 		os.Exit(-1)
@@ -176,6 +400,17 @@ func getCLIOptions() CLIOptions {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+
+			return
+		}
+	}
+
 	options := getCLIOptions()
 	p, err := project.Assemble(options.ProjectOptions)
 
@@ -42,8 +42,7 @@ type FileInfo struct {
 	FileTemplate
 }
 
-func (f FileInfo) Build(path string) error {
-	filename := path + "/" + f.Name
+func (f FileInfo) write(filename string) error {
 	file, err := os.Create(filename)
 
 	if err != nil {
@@ -60,6 +59,20 @@ func (f FileInfo) Build(path string) error {
 	f.Content(file)
 	f.Footer(file)
 
+	return nil
+}
+
+func (f FileInfo) Build(path string) error {
+	filename := path + "/" + f.Name
+
+	if err := f.write(filename); err != nil {
+		return err
+	}
+
+	if err := Reformat(filename, f.Style); err != nil {
+		return err
+	}
+
 	if f.Executable {
 		cmd := exec.Command("chmod", "+x", filename)
 
@@ -28,7 +28,74 @@ type ProjectOptions struct {
 	AuthorName             string
 	Language               int
 	ProjectType            int
+	BuildSystem            int
 	LibcollectionsFeatures bool
+	CmockaTests            bool
+	Style                  int
+	InstallPrefix          string
+	Email                  string
+	Container              bool
+	Config                 bool
+	LogRotate              bool
+
+	// Package formats created besides the debian one
+	PackageFormats []int
+
+	// systemd unit options, used by package projects
+	ServiceUser        string
+	ServiceType        string
+	ServiceHardening   bool
+	ServiceEnvironment bool
+	ServiceSocket      string
+	ServiceTimer       string
+}
+
+// HasService tells if a project is packaged with a systemd unit. Applications
+// always are, while scripts are command line tools which only get one when
+// they are run periodically by a timer.
+func (o ProjectOptions) HasService() bool {
+	if !o.PackageProject {
+		return false
+	}
+
+	return o.ProjectType == ApplicationProject ||
+		(o.ProjectType == ScriptProject && o.ServiceTimer != "")
+}
+
+// ConfigFile gives where the configuration file of a packaged application is
+// installed, or an empty string when it has none.
+func (o ProjectOptions) ConfigFile() string {
+	if !o.Config {
+		return ""
+	}
+
+	return "/etc/" + o.ProjectName + "/" + o.ProjectName + ".conf"
+}
+
+// LogFile gives where a packaged application service writes its log
+// messages, or an empty string when they are not rotated into a file.
+func (o ProjectOptions) LogFile() string {
+	if !o.LogRotate {
+		return ""
+	}
+
+	return "/var/log/" + o.ProjectName + "/" + o.ProjectName + ".log"
+}
+
+// HasPackageFormat tells if a package project is also created in one of the
+// additional package formats.
+func (o ProjectOptions) HasPackageFormat(format int) bool {
+	if !o.PackageProject {
+		return false
+	}
+
+	for _, f := range o.PackageFormats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
 }
 
 type Project interface {
@@ -58,15 +125,46 @@ func Dirtree(options ProjectOptions) map[string]string {
 		rootPath = cwd + "/package-" + options.ProjectName
 		dirtree["package"] = rootPath + "/pkg_install"
 		dirtree["debian"] = rootPath + "/pkg_install/debian"
+		dirtree["rpm"] = rootPath + "/pkg_install/rpm"
 		dirtree["misc"] = rootPath + "/pkg_install/misc"
+		dirtree["debian-source"] = rootPath + "/" + prefix + "/debian"
+		dirtree["debian-format"] = rootPath + "/" + prefix + "/debian/source"
+
+		if options.HasPackageFormat(ArchPackageFormat) {
+			dirtree["arch"] = rootPath + "/pkg_install/arch"
+		}
+
+		if options.HasPackageFormat(AlpinePackageFormat) {
+			dirtree["alpine"] = rootPath + "/pkg_install/alpine"
+		}
 	} else {
 		rootPath = cwd + "/" + options.ProjectName
 	}
 
 	dirtree["source"] = rootPath + "/" + prefix + "/src"
 
-	if options.Language == CLanguage {
+	if options.ProjectType == ScriptProject ||
+		options.ProjectType == ApplicationProject ||
+		options.ProjectType == LibraryProject {
+		dirtree["tests"] = rootPath + "/" + prefix + "/tests"
+	}
+
+	if options.ProjectType != ScriptProject && options.Language == CLanguage {
 		dirtree["header"] = rootPath + "/" + prefix + "/include"
+		dirtree["project"] = rootPath + "/" + prefix
+	}
+
+	// Applications and libraries may be cross compiled
+	if options.Language == CLanguage &&
+		(options.ProjectType == ApplicationProject ||
+			options.ProjectType == LibraryProject) {
+		switch options.BuildSystem {
+		case CMakeBuildSystem:
+			dirtree["toolchains"] = rootPath + "/" + prefix + "/cmake/toolchains"
+
+		case MesonBuildSystem:
+			dirtree["toolchains"] = rootPath + "/" + prefix + "/cross"
+		}
 	}
 
 	if options.ProjectType == XantePluginProject {
@@ -78,6 +176,10 @@ func Dirtree(options ProjectOptions) map[string]string {
 	}
 
 	if options.ProjectType == LibraryProject {
+		if options.BuildSystem == CMakeBuildSystem {
+			dirtree["cmake"] = rootPath + "/" + prefix + "/cmake"
+		}
+
 		dirtree["api-header"] = rootPath + "/" + prefix + "/include/api"
 		dirtree["internal-header"] = rootPath + "/" + prefix + "/include/internal"
 		dirtree["misc"] = rootPath + "/" + prefix + "/misc"
@@ -0,0 +1,191 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package base
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ProjectStyle = 1 + iota
+	LinuxStyle
+	GNUStyle
+	LLVMStyle
+)
+
+// templateIndentWidth is the width of an indentation level inside the C
+// templates, which may use a tab or 4 spaces for it.
+const templateIndentWidth = 4
+
+var supportedStyles = map[string]int{
+	"project": ProjectStyle,
+	"linux":   LinuxStyle,
+	"gnu":     GNUStyle,
+	"llvm":    LLVMStyle,
+}
+
+// StyleOption is a single clang-format option.
+type StyleOption struct {
+	Key   string
+	Value string
+}
+
+// Every style keeps the include order, since some headers (cmocka.h, for
+// example) depend on others being included before them.
+var styleOptions = map[int][]StyleOption{
+	ProjectStyle: {
+		{"BasedOnStyle", "LLVM"},
+		{"IndentWidth", "4"},
+		{"UseTab", "Never"},
+		{"BreakBeforeBraces", "Linux"},
+		{"IndentCaseLabels", "true"},
+		{"AllowShortIfStatementsOnASingleLine", "false"},
+		{"AllowShortFunctionsOnASingleLine", "None"},
+		{"ColumnLimit", "80"},
+		{"SortIncludes", "false"},
+	},
+	LinuxStyle: {
+		{"BasedOnStyle", "LLVM"},
+		{"IndentWidth", "8"},
+		{"TabWidth", "8"},
+		{"UseTab", "Always"},
+		{"BreakBeforeBraces", "Linux"},
+		{"IndentCaseLabels", "false"},
+		{"AllowShortIfStatementsOnASingleLine", "false"},
+		{"AllowShortFunctionsOnASingleLine", "None"},
+		{"ColumnLimit", "80"},
+		{"SortIncludes", "false"},
+	},
+	GNUStyle: {
+		{"BasedOnStyle", "GNU"},
+		{"SortIncludes", "false"},
+	},
+	LLVMStyle: {
+		{"BasedOnStyle", "LLVM"},
+		{"SortIncludes", "false"},
+	},
+}
+
+var styleIndent = map[int]string{
+	ProjectStyle: "    ",
+	LinuxStyle:   "\t",
+	GNUStyle:     "  ",
+	LLVMStyle:    "  ",
+}
+
+func StyleLookup(style string) (int, error) {
+	code := supportedStyles[style]
+
+	if code == 0 {
+		return -1, errors.New("Unknown code style")
+	}
+
+	return code, nil
+}
+
+func StyleKey(style int) (string, error) {
+	for k, v := range supportedStyles {
+		if v == style {
+			return k, nil
+		}
+	}
+
+	return "", errors.New("Unknown code style")
+}
+
+// StyleOptions gives the clang-format options describing a code style.
+func StyleOptions(style int) []StyleOption {
+	return styleOptions[style]
+}
+
+// StyleIndent gives a single indentation level of a code style.
+func StyleIndent(style int) string {
+	return styleIndent[style]
+}
+
+// reindent rewrites the leading whitespace of every line using the style
+// indentation. Whatever does not complete an indentation level is kept as
+// spaces, so aligned continuation lines and comments remain untouched.
+func reindent(content []byte, indent string) []byte {
+	lines := bytes.Split(content, []byte("\n"))
+
+	for i, line := range lines {
+		column := 0
+		text := bytes.TrimLeft(line, " \t")
+
+		for _, c := range line[:len(line)-len(text)] {
+			if c == '\t' {
+				column = (column/templateIndentWidth + 1) * templateIndentWidth
+			} else {
+				column++
+			}
+		}
+
+		if len(text) == 0 {
+			lines[i] = text
+			continue
+		}
+
+		lines[i] = []byte(strings.Repeat(indent, column/templateIndentWidth) +
+			strings.Repeat(" ", column%templateIndentWidth) + string(text))
+	}
+
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// Reformat formats a C source file according to a code style. Its indentation
+// is always fixed, the remaining rules are applied with clang-format, when it
+// is available.
+func Reformat(filename string, style int) error {
+	indent := StyleIndent(style)
+	extension := filepath.Ext(filename)
+
+	if indent == "" || (extension != ".c" && extension != ".h") {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(filename)
+
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filename, reindent(content, indent), 0644); err != nil {
+		return err
+	}
+
+	if _, err := exec.LookPath("clang-format"); err != nil {
+		return nil
+	}
+
+	var options []string
+
+	for _, option := range StyleOptions(style) {
+		options = append(options, option.Key+": "+option.Value)
+	}
+
+	formatStyle := "-style={" + strings.Join(options, ", ") + "}"
+	cmd := exec.Command("clang-format", "-i", formatStyle, filename)
+
+	return cmd.Run()
+}
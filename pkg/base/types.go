@@ -27,6 +27,7 @@ const (
 	ApplicationProject
 	LibraryProject
 	XantePluginProject
+	ScriptProject
 )
 
 const (
@@ -37,12 +38,24 @@ const (
 	RustLanguage
 )
 
+const (
+	CMakeBuildSystem = 1 + iota
+	MesonBuildSystem
+	MakeBuildSystem
+)
+
+const (
+	ArchPackageFormat = 1 + iota
+	AlpinePackageFormat
+)
+
 var supportedProjects = map[string]int{
 	"header":       SingleHeaderProject,
 	"source":       SingleSourceProject,
 	"application":  ApplicationProject,
 	"library":      LibraryProject,
 	"xante-plugin": XantePluginProject,
+	"script":       ScriptProject,
 }
 
 var supportedLanguages = map[string]int{
@@ -53,6 +66,17 @@ var supportedLanguages = map[string]int{
 	"rust":   RustLanguage,
 }
 
+var supportedBuildSystems = map[string]int{
+	"cmake": CMakeBuildSystem,
+	"meson": MesonBuildSystem,
+	"make":  MakeBuildSystem,
+}
+
+var supportedPackageFormats = map[string]int{
+	"arch":   ArchPackageFormat,
+	"alpine": AlpinePackageFormat,
+}
+
 func ProjectLookup(project string) (int, error) {
 	code := supportedProjects[project]
 
@@ -92,3 +116,43 @@ func LanguageKey(language int) (string, error) {
 
 	return "", errors.New("Unknown language")
 }
+
+func BuildSystemLookup(buildSystem string) (int, error) {
+	code := supportedBuildSystems[buildSystem]
+
+	if code == 0 {
+		return -1, errors.New("Unknown build system")
+	}
+
+	return code, nil
+}
+
+func BuildSystemKey(buildSystem int) (string, error) {
+	for k, v := range supportedBuildSystems {
+		if v == buildSystem {
+			return k, nil
+		}
+	}
+
+	return "", errors.New("Unknown build system")
+}
+
+func PackageFormatLookup(format string) (int, error) {
+	code := supportedPackageFormats[format]
+
+	if code == 0 {
+		return -1, errors.New("Unknown package format")
+	}
+
+	return code, nil
+}
+
+func PackageFormatKey(format int) (string, error) {
+	for k, v := range supportedPackageFormats {
+		if v == format {
+			return k, nil
+		}
+	}
+
+	return "", errors.New("Unknown package format")
+}
@@ -0,0 +1,364 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package pkgcheck
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The fields every binary package control file must have.
+var requiredControlFields = []string{
+	"Package",
+	"Version",
+	"Architecture",
+	"Maintainer",
+	"Description",
+}
+
+// Finding is a problem found inside one of the files of a package project.
+// Warnings point to something which may be a mistake, but don't keep the
+// package from being built.
+type Finding struct {
+	Filename string
+	Message  string
+	Warning  bool
+}
+
+// Report holds everything found while inspecting a package project.
+type Report struct {
+	Path     string
+	Findings []Finding
+}
+
+// Problems gives how many findings are not warnings.
+func (r *Report) Problems() int {
+	problems := 0
+
+	for _, finding := range r.Findings {
+		if !finding.Warning {
+			problems++
+		}
+	}
+
+	return problems
+}
+
+// addFinding appends a finding about filename, which is given relative to
+// the package project.
+func (r *Report) addFinding(warning bool, filename string, message string) {
+	if rel, err := filepath.Rel(r.Path, filename); err == nil {
+		filename = rel
+	}
+
+	r.Findings = append(r.Findings, Finding{
+		Filename: filename,
+		Message:  message,
+		Warning:  warning,
+	})
+}
+
+// add appends a problem about filename.
+func (r *Report) add(filename string, format string, args ...interface{}) {
+	r.addFinding(false, filename, fmt.Sprintf(format, args...))
+}
+
+// warn appends a warning about filename.
+func (r *Report) warn(filename string, format string, args ...interface{}) {
+	r.addFinding(true, filename, fmt.Sprintf(format, args...))
+}
+
+// packageName finds the project name through the package variable of its
+// build script.
+func packageName(script string) (string, error) {
+	content, err := ioutil.ReadFile(script)
+
+	if err != nil {
+		return "", err
+	}
+
+	re := regexp.MustCompile(`(?m)^package="([^"]+)"`)
+	match := re.FindStringSubmatch(string(content))
+
+	if match == nil {
+		return "", errors.New(script + ": package variable not found, " +
+			"is this a package project?")
+	}
+
+	return match[1], nil
+}
+
+// checkMaintainerScripts verifies that every debian maintainer script may be
+// executed by dpkg.
+func checkMaintainerScripts(report *Report, dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			report.add(dir, "debian maintainer scripts directory not found")
+			return nil
+		}
+
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+
+		filename := filepath.Join(dir, entry.Name())
+		content, err := ioutil.ReadFile(filename)
+
+		if err != nil {
+			return err
+		}
+
+		if entry.Mode().Perm()&0111 == 0 {
+			report.add(filename, "not executable, run chmod +x on it")
+		}
+
+		if !strings.HasPrefix(string(content), "#!") {
+			report.add(filename, "no shebang, add #!/bin/bash as its first line")
+		}
+	}
+
+	return nil
+}
+
+// logicalLines gives the lines of a shell script, with the ones continued
+// by a backslash joined together.
+func logicalLines(content string) []string {
+	content = strings.Replace(content, "\\\n", " ", -1)
+	return strings.Split(content, "\n")
+}
+
+// shellWords splits a shell command line into its words, removing their
+// quotes. Expansions are kept as they are.
+func shellWords(line string) []string {
+	var words []string
+	var word strings.Builder
+	var quote rune
+	inWord := false
+
+	for _, c := range line {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+
+		case quote != 0:
+			word.WriteRune(c)
+
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words
+}
+
+// checkControlFields verifies the control file written by the build script,
+// along with the arguments every package is built with.
+func checkControlFields(report *Report, script string) error {
+	content, err := ioutil.ReadFile(script)
+
+	if err != nil {
+		return err
+	}
+
+	lines := logicalLines(string(content))
+	fields := make(map[string]string)
+	locals := make(map[string]int)
+	assignments := make(map[string][]string)
+	heredoc := false
+	found := false
+	localRe := regexp.MustCompile(`^\s*local\s+(\w+)=\$(\d)$`)
+	assignRe := regexp.MustCompile(`^\s*(?:local\s+)?(\w+)=(.*)$`)
+
+	for _, line := range lines {
+		switch {
+		case strings.Contains(line, "<< CONTROL"):
+			heredoc = true
+			found = true
+
+		case heredoc && line == "CONTROL":
+			heredoc = false
+
+		case heredoc:
+			if i := strings.Index(line, ":"); i > 0 {
+				fields[line[:i]] = strings.TrimSpace(line[i+1:])
+			}
+
+		default:
+			if match := localRe.FindStringSubmatch(line); match != nil {
+				locals[match[1]], _ = strconv.Atoi(match[2])
+			} else if match := assignRe.FindStringSubmatch(line); match != nil {
+				words := shellWords(match[2])
+				value := ""
+
+				if len(words) > 0 {
+					value = words[0]
+				}
+
+				assignments[match[1]] = append(assignments[match[1]], value)
+			}
+		}
+	}
+
+	if !found {
+		report.add(script, "no control file is written, a CONTROL here-document is expected")
+		return nil
+	}
+
+	// Control fields given by the create_deb arguments
+	positional := make(map[int]string)
+
+	for _, field := range requiredControlFields {
+		value, ok := fields[field]
+
+		if !ok {
+			report.add(script, "the %s control field is missing", field)
+			continue
+		}
+
+		if value == "" {
+			report.add(script, "the %s control field is empty", field)
+			continue
+		}
+
+		if !strings.HasPrefix(value, "$") {
+			continue
+		}
+
+		variable := strings.Trim(value, "${}")
+
+		if n, ok := locals[variable]; ok {
+			positional[n] = field
+			continue
+		}
+
+		values, ok := assignments[variable]
+
+		if !ok {
+			report.add(script, "the %s control field uses %s, which is never set",
+				field, value)
+			continue
+		}
+
+		empty := true
+
+		for _, v := range values {
+			if v != "" {
+				empty = false
+			}
+		}
+
+		if empty {
+			report.add(script, "the %s control field uses %s, which is only set "+
+				"to an empty value", field, value)
+		}
+	}
+
+	var arguments []int
+
+	for n := range positional {
+		arguments = append(arguments, n)
+	}
+
+	sort.Ints(arguments)
+
+	for _, line := range lines {
+		words := shellWords(strings.TrimSpace(line))
+
+		if len(words) == 0 || words[0] != "create_deb" {
+			continue
+		}
+
+		for _, n := range arguments {
+			field := positional[n]
+
+			if n >= len(words) {
+				report.add(script, "create_deb is called without its argument %d, "+
+					"the %s control field would be empty", n, field)
+			} else if words[n] == "" {
+				report.add(script, "create_deb is called with an empty argument %d, "+
+					"the %s control field would be empty", n, field)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Check inspects a package project, created at path, without building it:
+// its debian maintainer scripts, systemd units, the control fields written by
+// its build script and whether all its version sources agree.
+func Check(path string) (*Report, error) {
+	install := filepath.Join(path, "pkg_install")
+	script := filepath.Join(install, "build-package.sh")
+	name, err := packageName(script)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Path: path,
+	}
+
+	if err := checkMaintainerScripts(report, filepath.Join(install, "debian")); err != nil {
+		return nil, err
+	}
+
+	if err := checkUnits(report, path, name); err != nil {
+		return nil, err
+	}
+
+	if err := checkControlFields(report, script); err != nil {
+		return nil, err
+	}
+
+	if err := checkVersions(report, path, name); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,246 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package pkgcheck
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// The well known keys of every unit section. This is not the full systemd
+// list, only the keys a package service is expected to use, so other ones are
+// only reported as warnings.
+var unitSectionKeys = map[string][]string{
+	"Unit": {
+		"Description", "Documentation", "After", "Before", "Requires",
+		"Wants", "BindsTo", "PartOf", "Conflicts", "Requisite",
+		"ConditionPathExists", "AssertPathExists", "StartLimitIntervalSec",
+		"StartLimitBurst", "DefaultDependencies",
+	},
+	"Install": {
+		"WantedBy", "RequiredBy", "Also", "Alias", "DefaultInstance",
+	},
+	"Service": {
+		"Type", "ExecStart", "ExecStartPre", "ExecStartPost", "ExecReload",
+		"ExecStop", "ExecStopPost", "PIDFile", "Restart", "RestartSec",
+		"TimeoutSec", "TimeoutStartSec", "TimeoutStopSec", "RemainAfterExit",
+		"User", "Group", "DynamicUser", "SupplementaryGroups",
+		"Environment", "EnvironmentFile", "WorkingDirectory",
+		"RuntimeDirectory", "StateDirectory", "LogsDirectory",
+		"CacheDirectory", "ConfigurationDirectory", "StandardOutput",
+		"StandardError", "SyslogIdentifier", "KillMode", "KillSignal",
+		"LimitNOFILE", "UMask", "NoNewPrivileges", "ProtectSystem",
+		"ProtectHome", "PrivateTmp", "PrivateDevices", "ProtectKernelTunables",
+		"ProtectKernelModules", "ProtectControlGroups", "RestrictSUIDSGID",
+		"RestrictRealtime", "LockPersonality", "ReadWritePaths",
+		"CapabilityBoundingSet", "AmbientCapabilities",
+	},
+	"Socket": {
+		"ListenStream", "ListenDatagram", "ListenSequentialPacket",
+		"ListenFIFO", "Accept", "SocketUser", "SocketGroup", "SocketMode",
+		"Service", "BindIPv6Only", "Backlog", "ReusePort",
+	},
+	"Timer": {
+		"OnCalendar", "OnActiveSec", "OnBootSec", "OnStartupSec",
+		"OnUnitActiveSec", "OnUnitInactiveSec", "Persistent",
+		"AccuracySec", "RandomizedDelaySec", "Unit", "WakeSystem",
+	},
+}
+
+// unitType describes the section a unit type has besides [Unit] and
+// [Install], along with the key prefix of the settings it can't work without.
+type unitType struct {
+	Extension string
+	Section   string
+	Required  string
+	Setting   string
+}
+
+var unitTypes = []unitType{
+	{".service", "Service", "ExecStart", "an ExecStart setting"},
+	{".socket", "Socket", "Listen", "a Listen* setting"},
+	{".timer", "Timer", "On", "an On* setting"},
+}
+
+// unitLines gives the lines of a unit file, with the ones continued by a
+// backslash joined together, along with their original line number.
+func unitLines(content string) ([]string, []int) {
+	var lines []string
+	var numbers []int
+	var current string
+	continued := false
+
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		// Comments are ignored, even inside continued lines
+		if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+
+		if !continued {
+			current = ""
+			numbers = append(numbers, i+1)
+		}
+
+		continued = strings.HasSuffix(trimmed, "\\")
+		current += strings.TrimSuffix(trimmed, "\\")
+
+		if continued {
+			current += " "
+			continue
+		}
+
+		lines = append(lines, current)
+	}
+
+	if continued {
+		lines = append(lines, current)
+	}
+
+	return lines, numbers
+}
+
+// checkUnit validates the sections and keys of a systemd unit file.
+func checkUnit(report *Report, filename string) error {
+	content, err := ioutil.ReadFile(filename)
+
+	if err != nil {
+		return err
+	}
+
+	var unit unitType
+
+	for _, t := range unitTypes {
+		if t.Extension == filepath.Ext(filename) {
+			unit = t
+		}
+	}
+
+	allowed := map[string]bool{"Unit": true, "Install": true, unit.Section: true}
+	sections := make(map[string]bool)
+	section := ""
+	required := false
+	lines, numbers := unitLines(string(content))
+
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				report.add(filename, "line %d: unterminated section header %s",
+					numbers[i], line)
+				continue
+			}
+
+			section = strings.Trim(line, "[]")
+			sections[section] = true
+
+			if !allowed[section] {
+				report.add(filename, "line %d: section [%s] does not belong to a %s "+
+					"unit", numbers[i], section, strings.TrimPrefix(unit.Extension, "."))
+			}
+
+			continue
+		}
+
+		separator := strings.Index(line, "=")
+
+		if separator < 0 {
+			report.add(filename, "line %d: expected a key=value assignment, found %q",
+				numbers[i], line)
+			continue
+		}
+
+		key := strings.TrimSpace(line[:separator])
+
+		if section == "" {
+			report.add(filename, "line %d: %s is outside of any section, move it "+
+				"below a section header", numbers[i], key)
+			continue
+		}
+
+		if key == "" || strings.ContainsAny(key, " \t") {
+			report.add(filename, "line %d: invalid key %q", numbers[i], key)
+			continue
+		}
+
+		known := false
+
+		for _, k := range unitSectionKeys[section] {
+			if k == key {
+				known = true
+			}
+		}
+
+		// X- keys are left to other programs by systemd itself
+		if !known && allowed[section] && !strings.HasPrefix(key, "X-") {
+			report.warn(filename, "line %d: unknown key %s in section [%s], check "+
+				"its spelling", numbers[i], key, section)
+		}
+
+		if section == unit.Section && strings.HasPrefix(key, unit.Required) &&
+			strings.TrimSpace(line[separator+1:]) != "" {
+			required = true
+		}
+	}
+
+	if !sections["Unit"] {
+		report.add(filename, "no [Unit] section found")
+	}
+
+	if !sections[unit.Section] {
+		report.add(filename, "no [%s] section found", unit.Section)
+	} else if !required {
+		report.add(filename, "section [%s] needs %s, the unit can't be started "+
+			"without it", unit.Section, unit.Setting)
+	}
+
+	return nil
+}
+
+// checkUnits validates every systemd unit of a package project, the ones
+// installed by the build script and the ones from the debian source package.
+func checkUnits(report *Report, path string, name string) error {
+	dirs := []string{
+		filepath.Join(path, "pkg_install", "misc"),
+		filepath.Join(path, name, "misc"),
+		filepath.Join(path, name, "debian"),
+	}
+
+	for _, dir := range dirs {
+		for _, t := range unitTypes {
+			units, err := filepath.Glob(filepath.Join(dir, "*"+t.Extension))
+
+			if err != nil {
+				return err
+			}
+
+			for _, unit := range units {
+				if err := checkUnit(report, unit); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package pkgcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// versionSource is a file holding a version, which is found by the first
+// submatch of its expression. Headers hold their version in several defines,
+// which are joined together.
+type versionSource struct {
+	Filename string
+	Pattern  string
+}
+
+var headerVersionRe = regexp.MustCompile(
+	`(?m)^#\s*define\s+(MAJOR_VERSION|MINOR_VERSION|RELEASE)\s+(\S+)`)
+
+// projectVersionSources gives the files the project version may come from,
+// in the same order build-package.sh looks for them.
+func projectVersionSources(path string, name string) []versionSource {
+	project := filepath.Join(path, name)
+
+	return []versionSource{
+		{filepath.Join(project, "Cargo.toml"), `(?m)^version\s*=\s*"([^"]*)"`},
+		{filepath.Join(project, "include", "lib"+name+".h"), ""},
+		{filepath.Join(project, "include", name+"_def.h"), ""},
+		{filepath.Join(project, "src", name), `(?m)^readonly VERSION="([^"]*)"`},
+		{filepath.Join(project, "src", "plugin.go"), `func plugin_version\(\) \*C\.char \{\s*return C\.CString\("([^"]*)"\)`},
+		{filepath.Join(project, "src", "plugin.c"), `CL_PLUGIN_SET_INFO\(\s*"[^"]*",\s*"([^"]*)"`},
+	}
+}
+
+// packageVersionSources gives the package files which also hold the project
+// version.
+func packageVersionSources(path string, name string) []versionSource {
+	install := filepath.Join(path, "pkg_install")
+
+	return []versionSource{
+		{filepath.Join(path, name, "debian", "changelog"), `^\S+ \(([^)]*)\)`},
+		{filepath.Join(install, "rpm", name+".spec"), `(?m)^Version:\s*(\S+)`},
+		{filepath.Join(install, "arch", "PKGBUILD"), `(?m)^pkgver=(\S+)`},
+		{filepath.Join(install, "alpine", "APKBUILD"), `(?m)^pkgver=(\S+)`},
+	}
+}
+
+// version gives the version held by a source, or an empty string when it has
+// none. A missing file is reported through ok.
+func (s versionSource) version() (string, bool, error) {
+	content, err := ioutil.ReadFile(s.Filename)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+
+		return "", false, err
+	}
+
+	if s.Pattern == "" {
+		defines := make(map[string]string)
+
+		for _, match := range headerVersionRe.FindAllStringSubmatch(string(content), -1) {
+			defines[match[1]] = match[2]
+		}
+
+		if len(defines) != 3 {
+			return "", true, nil
+		}
+
+		return defines["MAJOR_VERSION"] + "." + defines["MINOR_VERSION"] + "." +
+			defines["RELEASE"], true, nil
+	}
+
+	match := regexp.MustCompile(s.Pattern).FindStringSubmatch(string(content))
+
+	if match == nil {
+		return "", true, nil
+	}
+
+	return match[1], true, nil
+}
+
+// checkVersions verifies that the package files have the same version of the
+// project.
+func checkVersions(report *Report, path string, name string) error {
+	var project versionSource
+	var projectVersion string
+
+	for _, source := range projectVersionSources(path, name) {
+		version, ok, err := source.version()
+
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if version == "" {
+			report.add(source.Filename, "no version found, the package version "+
+				"can't be known")
+			return nil
+		}
+
+		project = source
+		projectVersion = version
+		break
+	}
+
+	if projectVersion == "" {
+		report.add(filepath.Join(path, name), "no version source found")
+		return nil
+	}
+
+	rel, err := filepath.Rel(path, project.Filename)
+
+	if err != nil {
+		rel = project.Filename
+	}
+
+	for _, source := range packageVersionSources(path, name) {
+		version, ok, err := source.version()
+
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if version == "" {
+			report.add(source.Filename, "no version found")
+			continue
+		}
+
+		if version != projectVersion {
+			report.add(source.Filename, "has version %s, but the project version "+
+				"is %s (from %s), update one of them", version, projectVersion, rel)
+		}
+	}
+
+	return nil
+}
@@ -27,9 +27,13 @@ import (
 
 type Application struct {
 	// Templates
-	sources  []base.FileInfo
-	headers  []base.FileInfo
-	makefile base.FileInfo
+	sources    []base.FileInfo
+	headers    []base.FileInfo
+	makefiles  []base.FileInfo
+	styles     []base.FileInfo
+	toolchains []base.FileInfo
+	containers []base.FileInfo
+	tests      []base.FileInfo
 
 	paths   map[string]string
 	Package common.Package
@@ -60,9 +64,39 @@ func (a Application) Build() error {
 		}
 	}
 
-	// create CMakeLists.txt
-	if err := a.makefile.Build(a.paths["makefile"]); err != nil {
-		return err
+	// create unit tests
+	for _, f := range a.tests {
+		if err := f.Build(a.paths["tests"]); err != nil {
+			return err
+		}
+	}
+
+	// create build system files
+	for _, f := range a.makefiles {
+		if err := f.Build(a.paths["makefile"]); err != nil {
+			return err
+		}
+	}
+
+	// create code style files
+	for _, f := range a.styles {
+		if err := f.Build(a.paths["project"]); err != nil {
+			return err
+		}
+	}
+
+	// create cross-compilation files
+	for _, f := range a.toolchains {
+		if err := f.Build(a.paths["toolchains"]); err != nil {
+			return err
+		}
+	}
+
+	// create container image files
+	for _, f := range a.containers {
+		if err := f.Build(a.paths["project"]); err != nil {
+			return err
+		}
 	}
 
 	// create package
@@ -129,7 +163,11 @@ func New(options base.ProjectOptions) (base.Project, error) {
 		paths:          paths,
 		sources:        createSources(options),
 		headers:        createHeaders(options),
-		makefile:       common.CreateMakefile(options),
+		makefiles:      common.CreateMakefiles(options),
+		styles:         common.CreateStyleFiles(options),
+		toolchains:     common.CreateToolchains(options),
+		containers:     common.CreateContainerFiles(options),
+		tests:          common.CreateTests(options, []string{options.ProjectName}),
 		Package:        common.NewPackage(options, paths),
 	}
 
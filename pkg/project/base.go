@@ -24,6 +24,7 @@ import (
 	"source-template/pkg/project/application"
 	"source-template/pkg/project/header"
 	"source-template/pkg/project/library"
+	"source-template/pkg/project/script"
 	"source-template/pkg/project/source"
 	"source-template/pkg/project/xante"
 )
@@ -52,6 +53,7 @@ func loadSupportedProjects() {
 	register(base.ApplicationProject, application.New)
 	register(base.LibraryProject, library.New)
 	register(base.XantePluginProject, xante.New)
+	register(base.ScriptProject, script.New)
 }
 
 // Assemble is responsible to initialize our supported project type and
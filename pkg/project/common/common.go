@@ -22,16 +22,52 @@ import (
 	"source-template/pkg/templates"
 )
 
-func CreateMakefile(options base.ProjectOptions) base.FileInfo {
-	filename := "CMakeLists.txt"
+// CreateMakefiles gives all build system files needed by a project, according
+// its chosen build system.
+func CreateMakefiles(options base.ProjectOptions) []base.FileInfo {
+	var files []base.FileInfo
 
 	if options.ProjectType == base.XantePluginProject {
 		switch options.Language {
 		case base.GoLanguage:
-			filename = "Makefile"
+			return append(files, createMakefile(options, "Makefile"))
 		}
 	}
 
+	switch options.BuildSystem {
+	case base.MesonBuildSystem:
+		for _, filename := range []string{"meson.build", "meson_options.txt"} {
+			fileOptions := base.FileOptions{
+				Executable:     false,
+				HeaderComment:  false,
+				ProjectOptions: options,
+				Name:           filename,
+			}
+
+			files = append(files, base.FileInfo{
+				FileOptions:  fileOptions,
+				FileTemplate: templates.NewMeson(fileOptions),
+			})
+		}
+
+	case base.MakeBuildSystem:
+		files = append(files, createMakefile(options, "Makefile"))
+
+	default:
+		files = append(files, createMakefile(options, "CMakeLists.txt"))
+
+		// Libraries also export themselves to be found by find_package()
+		if options.ProjectType == base.LibraryProject {
+			files = append(files,
+				createMakefile(options, "cmake/"+options.ProjectName+"Config.cmake.in"),
+				createMakefile(options, "CMakePresets.json"))
+		}
+	}
+
+	return files
+}
+
+func createMakefile(options base.ProjectOptions, filename string) base.FileInfo {
 	fileOptions := base.FileOptions{
 		Executable:     false,
 		HeaderComment:  false,
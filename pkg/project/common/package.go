@@ -18,18 +18,22 @@
 package common
 
 import (
-	"strings"
-
 	"source-template/pkg/base"
 	"source-template/pkg/templates"
 )
 
 type Package struct {
-	debian  []base.FileInfo
-	service base.FileInfo
-	builder base.FileInfo
-	options base.ProjectOptions
-	paths   map[string]string
+	debian      []base.FileInfo
+	source      []base.FileInfo
+	spec        base.FileInfo
+	misc        []base.FileInfo
+	arch        []base.FileInfo
+	alpine      []base.FileInfo
+	units       []base.FileInfo
+	sourceUnits []base.FileInfo
+	builder     base.FileInfo
+	options     base.ProjectOptions
+	paths       map[string]string
 }
 
 // Build builds all required and necessary package contents and structure.
@@ -41,10 +45,55 @@ func (p *Package) Build() error {
 		}
 	}
 
-	if err := p.service.Build(p.paths["misc"]); err != nil {
+	// create the debian source package files
+	for _, f := range p.source {
+		if err := f.Build(p.paths["debian-source"]); err != nil {
+			return err
+		}
+	}
+
+	if err := p.spec.Build(p.paths["rpm"]); err != nil {
 		return err
 	}
 
+	// create the Arch Linux and Alpine Linux package files
+	for _, f := range p.arch {
+		if err := f.Build(p.paths["arch"]); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range p.alpine {
+		if err := f.Build(p.paths["alpine"]); err != nil {
+			return err
+		}
+	}
+
+	// create the configuration and logrotate files, also installed by the
+	// debian source package
+	for _, f := range p.misc {
+		if err := f.Build(p.paths["misc"]); err != nil {
+			return err
+		}
+
+		if err := f.Build(p.paths["debian-source"]); err != nil {
+			return err
+		}
+	}
+
+	// create systemd units
+	for _, f := range p.units {
+		if err := f.Build(p.paths["misc"]); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range p.sourceUnits {
+		if err := f.Build(p.paths["debian-source"]); err != nil {
+			return err
+		}
+	}
+
 	if err := p.builder.Build(p.paths["package"]); err != nil {
 		return err
 	}
@@ -52,8 +101,12 @@ func (p *Package) Build() error {
 	return nil
 }
 
-func createDebianScripts(options base.ProjectOptions) []base.FileInfo {
+// createDebianScripts gives the debian maintainer scripts. The ones used by
+// debhelper are named after their package, and leave the systemd and ldconfig
+// handling to it.
+func createDebianScripts(options base.ProjectOptions, debhelper bool) []base.FileInfo {
 	var files []base.FileInfo
+	var prefix string
 	scripts := []string{
 		"preinst",
 		"prerm",
@@ -66,12 +119,16 @@ func createDebianScripts(options base.ProjectOptions) []base.FileInfo {
 		return files
 	}
 
+	if debhelper {
+		prefix = templates.DebianPackageNames(options)[0] + "."
+	}
+
 	for _, s := range scripts {
 		fileOptions := base.FileOptions{
 			Executable:     true,
 			HeaderComment:  true,
 			ProjectOptions: options,
-			Name:           s,
+			Name:           prefix + s,
 		}
 
 		files = append(files, base.FileInfo{
@@ -83,20 +140,185 @@ func createDebianScripts(options base.ProjectOptions) []base.FileInfo {
 	return files
 }
 
-func createSystemdService(options base.ProjectOptions) base.FileInfo {
+// createDebianSource gives the debian directory files, so the project may
+// also be built with dpkg-buildpackage.
+func createDebianSource(options base.ProjectOptions) []base.FileInfo {
+	var files []base.FileInfo
+	sources := []string{
+		"control",
+		"changelog",
+		"rules",
+		"copyright",
+		"source/format",
+	}
+
+	if !options.PackageProject {
+		return files
+	}
+
+	for _, pkg := range templates.DebianPackageNames(options) {
+		sources = append(sources, pkg+".install")
+	}
+
+	for _, s := range sources {
+		fileOptions := base.FileOptions{
+			Executable:     s == "rules",
+			HeaderComment:  false,
+			ProjectOptions: options,
+			Name:           s,
+		}
+
+		files = append(files, base.FileInfo{
+			FileOptions:  fileOptions,
+			FileTemplate: templates.NewDebian(fileOptions),
+		})
+	}
+
+	return files
+}
+
+func createSpec(options base.ProjectOptions) base.FileInfo {
 	fileOptions := base.FileOptions{
 		Executable:     false,
 		HeaderComment:  false,
 		ProjectOptions: options,
-		Name:           strings.ToLower(options.ProjectName) + ".service",
+		Name:           options.ProjectName + ".spec",
 	}
 
 	return base.FileInfo{
 		FileOptions:  fileOptions,
-		FileTemplate: templates.NewText(fileOptions),
+		FileTemplate: templates.NewSpec(fileOptions),
 	}
 }
 
+// createMiscFiles gives the default configuration file and the logrotate
+// configuration of a packaged application, when it has them.
+func createMiscFiles(options base.ProjectOptions) []base.FileInfo {
+	var files []base.FileInfo
+	var names []string
+
+	if options.ConfigFile() != "" {
+		names = append(names, options.ProjectName+".conf")
+	}
+
+	if options.LogFile() != "" {
+		names = append(names, options.ProjectName+".logrotate")
+	}
+
+	for _, name := range names {
+		fileOptions := base.FileOptions{
+			Executable:     false,
+			HeaderComment:  false,
+			ProjectOptions: options,
+			Name:           name,
+		}
+
+		files = append(files, base.FileInfo{
+			FileOptions:  fileOptions,
+			FileTemplate: templates.NewText(fileOptions),
+		})
+	}
+
+	return files
+}
+
+// createArchPackage gives the PKGBUILD of a project, when it is also packaged
+// for Arch Linux.
+func createArchPackage(options base.ProjectOptions) []base.FileInfo {
+	var files []base.FileInfo
+
+	if !options.HasPackageFormat(base.ArchPackageFormat) {
+		return files
+	}
+
+	fileOptions := base.FileOptions{
+		Executable:     false,
+		HeaderComment:  false,
+		ProjectOptions: options,
+		Name:           "PKGBUILD",
+	}
+
+	return append(files, base.FileInfo{
+		FileOptions:  fileOptions,
+		FileTemplate: templates.NewPkgbuild(fileOptions),
+	})
+}
+
+// createAlpinePackage gives the APKBUILD of a project, when it is also
+// packaged for Alpine Linux, along with its OpenRC init script and the
+// script creating the service user.
+func createAlpinePackage(options base.ProjectOptions) []base.FileInfo {
+	var files []base.FileInfo
+
+	if !options.HasPackageFormat(base.AlpinePackageFormat) {
+		return files
+	}
+
+	fileOptions := base.FileOptions{
+		Executable:     false,
+		HeaderComment:  false,
+		ProjectOptions: options,
+		Name:           "APKBUILD",
+	}
+
+	files = append(files, base.FileInfo{
+		FileOptions:  fileOptions,
+		FileTemplate: templates.NewPkgbuild(fileOptions),
+	})
+
+	if options.HasService() && options.ServiceUser != "" {
+		fileOptions := base.FileOptions{
+			Executable:     true,
+			HeaderComment:  false,
+			ProjectOptions: options,
+			Name:           options.ProjectName + ".pre-install",
+		}
+
+		files = append(files, base.FileInfo{
+			FileOptions:  fileOptions,
+			FileTemplate: templates.NewPkgbuild(fileOptions),
+		})
+	}
+
+	if templates.HasInitScript(options) {
+		fileOptions := base.FileOptions{
+			Executable:     true,
+			HeaderComment:  false,
+			ProjectOptions: options,
+			Name:           options.ProjectName + ".initd",
+		}
+
+		files = append(files, base.FileInfo{
+			FileOptions:  fileOptions,
+			FileTemplate: templates.NewUnit(fileOptions),
+		})
+	}
+
+	return files
+}
+
+// createSystemdUnits gives the project service, along with its optional
+// socket and timer units.
+func createSystemdUnits(options base.ProjectOptions) []base.FileInfo {
+	var files []base.FileInfo
+
+	for _, u := range templates.SystemdUnits(options) {
+		fileOptions := base.FileOptions{
+			Executable:     false,
+			HeaderComment:  false,
+			ProjectOptions: options,
+			Name:           u,
+		}
+
+		files = append(files, base.FileInfo{
+			FileOptions:  fileOptions,
+			FileTemplate: templates.NewUnit(fileOptions),
+		})
+	}
+
+	return files
+}
+
 func createBuildScript(options base.ProjectOptions) base.FileInfo {
 	fileOptions := base.FileOptions{
 		Executable:     true,
@@ -112,11 +334,22 @@ func createBuildScript(options base.ProjectOptions) base.FileInfo {
 }
 
 func NewPackage(options base.ProjectOptions, paths map[string]string) Package {
+	// debian, RPM, Arch and Alpine packages are always installed under /usr
+	sourceOptions := options
+	sourceOptions.InstallPrefix = "/usr"
+
 	return Package{
 		options: options,
 		paths:   paths,
-		debian:  createDebianScripts(options),
-		service: createSystemdService(options),
+		debian:  createDebianScripts(options, false),
+		source:  append(createDebianSource(options), createDebianScripts(options, true)...),
+		spec:    createSpec(sourceOptions),
+		misc:    createMiscFiles(options),
+		arch:    createArchPackage(sourceOptions),
+		alpine:  createAlpinePackage(sourceOptions),
+		units:   createSystemdUnits(options),
 		builder: createBuildScript(options),
+
+		sourceUnits: createSystemdUnits(sourceOptions),
 	}
 }
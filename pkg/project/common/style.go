@@ -0,0 +1,45 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package common
+
+import (
+	"source-template/pkg/base"
+	"source-template/pkg/templates"
+)
+
+// CreateStyleFiles gives the editor and tooling configuration files of a C
+// project, following its chosen code style.
+func CreateStyleFiles(options base.ProjectOptions) []base.FileInfo {
+	var files []base.FileInfo
+
+	for _, filename := range []string{".clang-format", ".editorconfig", ".clangd"} {
+		fileOptions := base.FileOptions{
+			Executable:     false,
+			HeaderComment:  false,
+			ProjectOptions: options,
+			Name:           filename,
+		}
+
+		files = append(files, base.FileInfo{
+			FileOptions:  fileOptions,
+			FileTemplate: templates.NewStyle(fileOptions),
+		})
+	}
+
+	return files
+}
@@ -0,0 +1,57 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package common
+
+import (
+	"source-template/pkg/base"
+	"source-template/pkg/templates"
+)
+
+// CreateTests gives the unit test sources of a C project, one for each of
+// its modules, plus the assertion header when cmocka is not being used.
+func CreateTests(options base.ProjectOptions, modules []string) []base.FileInfo {
+	var files []base.FileInfo
+
+	for _, m := range modules {
+		fileOptions := base.FileOptions{
+			ProjectOptions: options,
+			HeaderComment:  true,
+			Name:           base.AddExtension("test_"+m, ".c"),
+		}
+
+		files = append(files, base.FileInfo{
+			FileOptions:  fileOptions,
+			FileTemplate: templates.NewSource(fileOptions),
+		})
+	}
+
+	if !options.CmockaTests {
+		fileOptions := base.FileOptions{
+			ProjectOptions: options,
+			HeaderComment:  true,
+			Name:           "test_assert.h",
+		}
+
+		files = append(files, base.FileInfo{
+			FileOptions:  fileOptions,
+			FileTemplate: templates.NewHeader(fileOptions, nil),
+		})
+	}
+
+	return files
+}
@@ -26,10 +26,14 @@ import (
 )
 
 type Library struct {
-	sources  []base.FileInfo
-	headers  []base.FileInfo
-	makefile base.FileInfo
-	symbol   base.FileInfo
+	sources    []base.FileInfo
+	headers    []base.FileInfo
+	makefiles  []base.FileInfo
+	styles     []base.FileInfo
+	toolchains []base.FileInfo
+	tests      []base.FileInfo
+	symbol     base.FileInfo
+	pkgconfig  base.FileInfo
 
 	paths   map[string]string
 	Package common.Package
@@ -60,9 +64,32 @@ func (l Library) Build() error {
 		}
 	}
 
-	// create CMakeLists.txt
-	if err := l.makefile.Build(l.paths["makefile"]); err != nil {
-		return err
+	// create unit tests
+	for _, f := range l.tests {
+		if err := f.Build(l.paths["tests"]); err != nil {
+			return err
+		}
+	}
+
+	// create build system files
+	for _, f := range l.makefiles {
+		if err := f.Build(l.paths["makefile"]); err != nil {
+			return err
+		}
+	}
+
+	// create code style files
+	for _, f := range l.styles {
+		if err := f.Build(l.paths["project"]); err != nil {
+			return err
+		}
+	}
+
+	// create cross-compilation files
+	for _, f := range l.toolchains {
+		if err := f.Build(l.paths["toolchains"]); err != nil {
+			return err
+		}
 	}
 
 	// create symbols file
@@ -70,6 +97,11 @@ func (l Library) Build() error {
 		return err
 	}
 
+	// create pkg-config file
+	if err := l.pkgconfig.Build(l.paths["misc"]); err != nil {
+		return err
+	}
+
 	// create package
 	if l.PackageProject {
 		l.Package.Build()
@@ -128,7 +160,7 @@ func createHeaders(options base.ProjectOptions, sources []string) []base.FileInf
 	return files
 }
 
-func createSymbol(options base.ProjectOptions) base.FileInfo {
+func createSymbol(options base.ProjectOptions, sources []string) base.FileInfo {
 	fileOptions := base.FileOptions{
 		Executable:     false,
 		HeaderComment:  false,
@@ -138,7 +170,21 @@ func createSymbol(options base.ProjectOptions) base.FileInfo {
 
 	return base.FileInfo{
 		FileOptions:  fileOptions,
-		FileTemplate: templates.NewSymbol(fileOptions),
+		FileTemplate: templates.NewSymbol(fileOptions, sources),
+	}
+}
+
+func createPkgConfig(options base.ProjectOptions) base.FileInfo {
+	fileOptions := base.FileOptions{
+		Executable:     false,
+		HeaderComment:  false,
+		ProjectOptions: options,
+		Name:           "lib" + options.ProjectName + ".pc.in",
+	}
+
+	return base.FileInfo{
+		FileOptions:  fileOptions,
+		FileTemplate: templates.NewText(fileOptions),
 	}
 }
 
@@ -151,8 +197,12 @@ func New(options base.ProjectOptions) (base.Project, error) {
 		paths:          paths,
 		ProjectOptions: options,
 		headers:        createHeaders(options, sourceFilenames),
-		makefile:       common.CreateMakefile(options),
-		symbol:         createSymbol(options),
+		makefiles:      common.CreateMakefiles(options),
+		styles:         common.CreateStyleFiles(options),
+		toolchains:     common.CreateToolchains(options),
+		tests:          common.CreateTests(options, sourceFilenames),
+		symbol:         createSymbol(options, sourceFilenames),
+		pkgconfig:      createPkgConfig(options),
 		Package:        common.NewPackage(options, paths),
 	}, nil
 }
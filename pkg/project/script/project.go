@@ -0,0 +1,116 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package script
+
+import (
+	"os"
+
+	"source-template/pkg/base"
+	"source-template/pkg/project/common"
+	"source-template/pkg/templates"
+)
+
+type Script struct {
+	script base.FileInfo
+	tests  []base.FileInfo
+
+	paths   map[string]string
+	Package common.Package
+	base.ProjectOptions
+}
+
+func (s Script) Build() error {
+	// create root path and subdirs
+	for _, path := range s.paths {
+		err := os.MkdirAll(path, 0755)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// create the script itself
+	if err := s.script.Build(s.paths["source"]); err != nil {
+		return err
+	}
+
+	// create bats tests
+	for _, f := range s.tests {
+		if err := f.Build(s.paths["tests"]); err != nil {
+			return err
+		}
+	}
+
+	// create package
+	if s.PackageProject {
+		if err := s.Package.Build(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createScript(options base.ProjectOptions) base.FileInfo {
+	fileOptions := base.FileOptions{
+		Executable:     true,
+		HeaderComment:  true,
+		ProjectOptions: options,
+		Name:           options.ProjectName,
+	}
+
+	return base.FileInfo{
+		FileOptions:  fileOptions,
+		FileTemplate: templates.NewBash(fileOptions),
+	}
+}
+
+func createTests(options base.ProjectOptions) []base.FileInfo {
+	var files []base.FileInfo
+	tests := []string{
+		options.ProjectName,
+	}
+
+	for _, t := range tests {
+		fileOptions := base.FileOptions{
+			Executable:     false,
+			HeaderComment:  true,
+			ProjectOptions: options,
+			Name:           base.AddExtension(t, ".bats"),
+		}
+
+		files = append(files, base.FileInfo{
+			FileOptions:  fileOptions,
+			FileTemplate: templates.NewBash(fileOptions),
+		})
+	}
+
+	return files
+}
+
+func New(options base.ProjectOptions) (base.Project, error) {
+	paths := base.Dirtree(options)
+
+	return &Script{
+		paths:          paths,
+		ProjectOptions: options,
+		script:         createScript(options),
+		tests:          createTests(options),
+		Package:        common.NewPackage(options, paths),
+	}, nil
+}
@@ -26,10 +26,11 @@ import (
 )
 
 type XantePlugin struct {
-	sources  []base.FileInfo
-	headers  []base.FileInfo
-	makefile base.FileInfo
-	script   base.FileInfo
+	sources   []base.FileInfo
+	headers   []base.FileInfo
+	makefiles []base.FileInfo
+	styles    []base.FileInfo
+	script    base.FileInfo
 
 	paths   map[string]string
 	Package common.Package
@@ -60,9 +61,18 @@ func (x XantePlugin) Build() error {
 		}
 	}
 
-	// create CMakeLists.txt
-	if err := x.makefile.Build(x.paths["makefile"]); err != nil {
-		return err
+	// create build system files
+	for _, f := range x.makefiles {
+		if err := f.Build(x.paths["makefile"]); err != nil {
+			return err
+		}
+	}
+
+	// create code style files
+	for _, f := range x.styles {
+		if err := f.Build(x.paths["project"]); err != nil {
+			return err
+		}
 	}
 
 	// create application script
@@ -144,12 +154,13 @@ func createPluginScript(options base.ProjectOptions) base.FileInfo {
 }
 
 func New(options base.ProjectOptions) (base.Project, error) {
-	var headers []base.FileInfo
+	var headers, styles []base.FileInfo
 	paths := base.Dirtree(options)
 
-	// Only C plugins have header files
+	// Only C plugins have header files and code style
 	if options.Language == base.CLanguage {
 		headers = createHeaders(options)
+		styles = common.CreateStyleFiles(options)
 	}
 
 	return &XantePlugin{
@@ -157,7 +168,8 @@ func New(options base.ProjectOptions) (base.Project, error) {
 		sources:        createSources(options),
 		headers:        headers,
 		ProjectOptions: options,
-		makefile:       common.CreateMakefile(options),
+		makefiles:      common.CreateMakefiles(options),
+		styles:         styles,
 		script:         createPluginScript(options),
 		Package:        common.NewPackage(options, paths),
 	}, nil
@@ -0,0 +1,174 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package symbols
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// VersionNode is a single version node of a linker version script.
+type VersionNode struct {
+	Name   string
+	Global []string
+	Local  []string
+	Parent string
+}
+
+// VersionScript holds all version nodes of a version script, in the same
+// order they were declared.
+type VersionScript struct {
+	Nodes []VersionNode
+}
+
+var (
+	scriptComment = regexp.MustCompile(`(?s)/\*.*?\*/|#[^\n]*`)
+	scriptToken   = regexp.MustCompile(`[{};:]|[^\s{};:]+`)
+)
+
+// ParseVersionScript parses the content of a linker version script.
+func ParseVersionScript(content string) (*VersionScript, error) {
+	var script VersionScript
+	tokens := scriptToken.FindAllString(scriptComment.ReplaceAllString(content, ""), -1)
+
+	for i := 0; i < len(tokens); {
+		var node VersionNode
+
+		// An anonymous node starts directly with its body
+		if tokens[i] != "{" {
+			node.Name = tokens[i]
+			i++
+		}
+
+		if i >= len(tokens) || tokens[i] != "{" {
+			return nil, fmt.Errorf("expected '{' after version node '%s'", node.Name)
+		}
+
+		section := "global"
+		i++
+
+		for i < len(tokens) && tokens[i] != "}" {
+			if i+1 >= len(tokens) {
+				return nil, errors.New("unexpected end of version script")
+			}
+
+			switch tokens[i+1] {
+			case ":":
+				section = tokens[i]
+
+			case ";":
+				if section == "local" {
+					node.Local = append(node.Local, tokens[i])
+				} else {
+					node.Global = append(node.Global, tokens[i])
+				}
+
+			default:
+				return nil, fmt.Errorf("unexpected '%s' inside version node '%s'",
+					tokens[i+1], node.Name)
+			}
+
+			i += 2
+		}
+
+		if i >= len(tokens) {
+			return nil, fmt.Errorf("version node '%s' is not closed", node.Name)
+		}
+
+		// Skips the '}' and takes the node dependency, if any
+		i++
+
+		if i < len(tokens) && tokens[i] != ";" {
+			node.Parent = tokens[i]
+			i++
+		}
+
+		if i >= len(tokens) || tokens[i] != ";" {
+			return nil, fmt.Errorf("expected ';' after version node '%s'", node.Name)
+		}
+
+		i++
+		script.Nodes = append(script.Nodes, node)
+	}
+
+	return &script, nil
+}
+
+// Exported gives all symbols exported by the version script.
+func (v *VersionScript) Exported() []string {
+	var symbols []string
+
+	for _, node := range v.Nodes {
+		symbols = append(symbols, node.Global...)
+	}
+
+	return symbols
+}
+
+// Latest gives the last declared version node.
+func (v *VersionScript) Latest() *VersionNode {
+	if len(v.Nodes) == 0 {
+		return nil
+	}
+
+	return &v.Nodes[len(v.Nodes)-1]
+}
+
+// String gives the version script content, in the same format used when
+// creating it.
+func (v *VersionScript) String() string {
+	var s bytes.Buffer
+
+	for i, node := range v.Nodes {
+		if i > 0 {
+			s.WriteString("\n")
+		}
+
+		if node.Name != "" {
+			s.WriteString(node.Name + " ")
+		}
+
+		s.WriteString("{\n")
+
+		if len(node.Global) > 0 {
+			s.WriteString("\tglobal:\n")
+
+			for _, symbol := range node.Global {
+				s.WriteString("\t\t" + symbol + ";\n")
+			}
+		}
+
+		if len(node.Local) > 0 {
+			s.WriteString("\tlocal:\n")
+
+			for _, symbol := range node.Local {
+				s.WriteString("\t\t" + symbol + ";\n")
+			}
+		}
+
+		if node.Parent != "" {
+			s.WriteString("} " + node.Parent + ";\n")
+		} else {
+			s.WriteString("};\n")
+		}
+	}
+
+	return s.String()
+}
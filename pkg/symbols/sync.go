@@ -0,0 +1,203 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package symbols
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"source-template/pkg/templates"
+)
+
+// Report describes what a synchronization did (or would do) to a library
+// version script.
+type Report struct {
+	Filename string   // The version script path.
+	Node     string   // The version node which received the new symbols.
+	NewNode  bool     // Tells if Node was created by the synchronization.
+	Added    []string // Public symbols missing from the version script.
+	Removed  []string // Exported symbols no longer found in the sources.
+}
+
+// libraryName finds the library name through its version script, which must
+// be the only one inside the misc directory.
+func libraryName(path string) (string, error) {
+	scripts, err := filepath.Glob(filepath.Join(path, "misc", "lib*.sym"))
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(scripts) != 1 {
+		return "", fmt.Errorf("%s: expected a single misc/lib<name>.sym file", path)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(scripts[0]), ".sym")
+
+	return strings.TrimPrefix(name, "lib"), nil
+}
+
+// libraryVersion gives the library MAJOR_VERSION and MINOR_VERSION defines
+// from its main header.
+func libraryVersion(header string) (string, string, error) {
+	var version [2]string
+	content, err := ioutil.ReadFile(header)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	for i, define := range []string{"MAJOR_VERSION", "MINOR_VERSION"} {
+		re := regexp.MustCompile(`define\s+` + define + `\s+(\d+)`)
+		match := re.FindSubmatch(content)
+
+		if match == nil {
+			return "", "", fmt.Errorf("%s: %s not found", header, define)
+		}
+
+		version[i] = string(match[1])
+	}
+
+	return version[0], version[1], nil
+}
+
+// sourceSymbols gives all __PUB_API__ functions from the library sources.
+func sourceSymbols(path string) ([]string, error) {
+	var symbols []string
+	sources, err := filepath.Glob(filepath.Join(path, "src", "*.c"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range sources {
+		content, err := ioutil.ReadFile(source)
+
+		if err != nil {
+			return nil, err
+		}
+
+		symbols = append(symbols, templates.PublicSymbols(string(content))...)
+	}
+
+	sort.Strings(symbols)
+
+	return symbols, nil
+}
+
+// difference gives the items from a which are not inside b.
+func difference(a, b []string) []string {
+	var diff []string
+	items := make(map[string]bool)
+
+	for _, item := range b {
+		items[item] = true
+	}
+
+	for _, item := range a {
+		if !items[item] {
+			diff = append(diff, item)
+			items[item] = true
+		}
+	}
+
+	return diff
+}
+
+// Sync compares the __PUB_API__ functions of a library, created at path, with
+// its version script. New symbols are added to the node of the current library
+// version, which is created when the version from lib<name>.h has changed.
+// The version script is only written when write is true.
+func Sync(path string, write bool) (*Report, error) {
+	name, err := libraryName(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filename := filepath.Join(path, "misc", "lib"+name+".sym")
+	content, err := ioutil.ReadFile(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	script, err := ParseVersionScript(string(content))
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+
+	if script.Latest() == nil {
+		return nil, fmt.Errorf("%s: no version node found", filename)
+	}
+
+	major, minor, err := libraryVersion(filepath.Join(path, "include", "lib"+name+".h"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	symbols, err := sourceSymbols(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Filename: filename,
+		Node:     fmt.Sprintf("LIB%s_%s.%s", strings.ToUpper(name), major, minor),
+		Added:    difference(symbols, script.Exported()),
+		Removed:  difference(script.Exported(), symbols),
+	}
+
+	if len(report.Added) == 0 {
+		return report, nil
+	}
+
+	if latest := script.Latest(); latest.Name == report.Node {
+		latest.Global = append(latest.Global, report.Added...)
+	} else {
+		for _, node := range script.Nodes {
+			if node.Name == report.Node {
+				return nil, errors.New("version node " + report.Node +
+					" is not the latest one, the library version went backwards")
+			}
+		}
+
+		report.NewNode = true
+		script.Nodes = append(script.Nodes, VersionNode{
+			Name:   report.Node,
+			Global: report.Added,
+			Parent: latest.Name,
+		})
+	}
+
+	if write {
+		if err := ioutil.WriteFile(filename, []byte(script.String()), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
@@ -28,10 +28,140 @@ const pluginScriptContent = `
 jerminus -j {{.ProjectName}}.jtf -N
 `
 
+const scriptContent = `
+set -euo pipefail
+
+readonly APP_NAME="{{.ProjectName}}"
+readonly VERSION="0.1.1"
+
+verbose=0
+
+usage()
+{
+    echo "Usage: $APP_NAME [OPTIONS]"
+    echo "A brief description."
+    echo
+    echo "Options:"
+    echo -e " -h\tShows this help screen."
+    echo -e " -v\tShows current $APP_NAME version."
+    echo -e " -V\tEnables verbose messages."
+    echo
+}
+
+version()
+{
+    echo "$APP_NAME - Version $VERSION"
+}
+
+log()
+{
+    local level=$1
+
+    shift
+    echo "$(date '+%Y-%m-%d %H:%M:%S') $APP_NAME [$level] $*" >&2
+}
+
+log_debug()
+{
+    if [ $verbose -ne 0 ]; then
+        log DEBUG "$@"
+    fi
+}
+
+log_info()
+{
+    log INFO "$@"
+}
+
+log_warning()
+{
+    log WARNING "$@"
+}
+
+log_error()
+{
+    log ERROR "$@"
+}
+
+die()
+{
+    log_error "$@"
+    exit 1
+}
+
+main()
+{
+    local opts
+
+    while getopts hvV opts; do
+        case $opts in
+            h)
+                usage
+                exit 1
+                ;;
+
+            v)
+                version
+                exit 1
+                ;;
+
+            V)
+                verbose=1
+                ;;
+
+            ?)
+                exit 1
+                ;;
+        esac
+    done
+
+    shift $((OPTIND - 1))
+    log_debug "Starting $APP_NAME"
+}
+
+main "$@"
+`
+
+const scriptTestContent = `
+setup()
+{
+    SCRIPT="$BATS_TEST_DIRNAME/../src/{{.ProjectName}}"
+}
+
+@test "shows the help screen" {
+    run "$SCRIPT" -h
+    [ "$status" -eq 1 ]
+    [[ "$output" == *"Usage:"* ]]
+}
+
+@test "shows the version" {
+    run "$SCRIPT" -v
+    [ "$status" -eq 1 ]
+    [[ "$output" == *"Version"* ]]
+}
+
+@test "rejects unknown options" {
+    run "$SCRIPT" -x
+    [ "$status" -ne 0 ]
+}
+`
+
 const packageBuildScriptContent = `
 arch=""
 mode="debug"
+type="deb"
 package="{{.ProjectName}}"
+prefix="{{.Prefix}}"
+source_dir="../$package{{if eq .ProjectTypeName "xante-plugin"}}/src{{end}}"
+
+# Target architecture details, filled by set_arch
+dpkg_arch=""
+rpm_arch=""
+goarch=""
+goarm=""
+rust_target=""
+cross_triplet=""
+build_dir=""
 
 usage()
 {
@@ -40,27 +170,111 @@ usage()
     echo
     echo "Options:"
     echo -e " -h\tShows this help screen."
+    echo -e " -a\tThe package architecture (386, amd64, armhf, arm64 or riscv64)."
     echo -e " -R\tCompiles the application in release mode (debug is default)."
+    echo -e " -t\tThe package type (deb or rpm, deb is default)."
+    echo -e " -s\tCreates a source release tarball instead of a package."
     echo
 }
 
 validate_arch()
 {
-    if [ "$arch" != "386" -a "$arch" != "amd64" ]; then
-        echo -1
-    else
-        echo 0
+    case "$arch" in
+        386|amd64|armhf|arm64|riscv64)
+            echo 0
+            ;;
+
+        *)
+            echo -1
+            ;;
+    esac
+}
+
+host_arch()
+{
+    if command -v dpkg > /dev/null; then
+        dpkg --print-architecture
+        return
     fi
+
+    case "$(uname -m)" in
+        x86_64) echo amd64 ;;
+        i?86) echo i386 ;;
+        armv7*) echo armhf ;;
+        aarch64) echo arm64 ;;
+        *) uname -m ;;
+    esac
+}
+
+# Maps the chosen architecture to the names used by each toolchain.
+set_arch()
+{
+    case "$arch" in
+        386)
+            dpkg_arch="i386"
+            rpm_arch="i686"
+            goarch="386"
+            rust_target="i686-unknown-linux-gnu"
+            cross_triplet="i686-linux-gnu"
+            ;;
+
+        amd64)
+            dpkg_arch="amd64"
+            rpm_arch="x86_64"
+            goarch="amd64"
+            rust_target="x86_64-unknown-linux-gnu"
+            cross_triplet="x86_64-linux-gnu"
+            ;;
+
+        armhf)
+            dpkg_arch="armhf"
+            rpm_arch="armv7hl"
+            goarch="arm"
+            goarm="7"
+            rust_target="armv7-unknown-linux-gnueabihf"
+            cross_triplet="arm-linux-gnueabihf"
+            ;;
+
+        arm64)
+            dpkg_arch="arm64"
+            rpm_arch="aarch64"
+            goarch="arm64"
+            rust_target="aarch64-unknown-linux-gnu"
+            cross_triplet="aarch64-linux-gnu"
+            ;;
+
+        riscv64)
+            dpkg_arch="riscv64"
+            rpm_arch="riscv64"
+            goarch="riscv64"
+            rust_target="riscv64gc-unknown-linux-gnu"
+            cross_triplet="riscv64-linux-gnu"
+            ;;
+    esac
+
+    # Native builds use the host compiler
+    if [ "$dpkg_arch" = "$(host_arch)" ]; then
+        cross_triplet=""
+    fi
+
+    build_dir="build-$arch"
 }
 
 rust_compile()
 {
+    local flags="--target $rust_target"
+
     if [ "$mode" = "release" ]; then
-        (cd ../$package && cargo build --release || exit -1)
-    else
-        (cd ../$package && cargo build || exit -1)
+        flags="$flags --release"
+    fi
+
+    if [ -n "$cross_triplet" ]; then
+        local linker_var=CARGO_TARGET_$(echo $rust_target | tr 'a-z-' 'A-Z_')_LINKER
+        export $linker_var=$cross_triplet-gcc
     fi
 
+    (cd $source_dir && cargo build $flags || exit -1)
+
     if [ $? != 0 ]; then
         return -1
     fi
@@ -68,9 +282,30 @@ rust_compile()
     return 0
 }
 
+script_compile()
+{
+    bash -n ../$package/src/$package || return -1
+
+    if command -v bats > /dev/null; then
+        bats ../$package/tests || return -1
+    fi
+
+    return 0
+}
+
 go_compile()
 {
-    (cd ../$package/cmd/$package && GOARCH=$arch go build || exit -1)
+    local cross=""
+
+    if [ -n "$cross_triplet" ]; then
+        cross="CGO_ENABLED=1 CC=$cross_triplet-gcc"
+    fi
+
+    if [ -e $source_dir/Makefile ]; then
+        (env GOARCH=$goarch GOARM=$goarm $cross make -C $source_dir || exit -1)
+    else
+        (cd $source_dir/cmd/$package && env GOARCH=$goarch GOARM=$goarm $cross go build || exit -1)
+    fi
 
     if [ $? != 0 ]; then
         return -1
@@ -79,14 +314,63 @@ go_compile()
     return 0
 }
 
+{{- if eq .BuildSystemName "make"}}
+# Gives the variables of every make invocation, so the install step doesn't
+# rebuild the project with different ones.
+make_variables()
+{
+    local flags="BUILDDIR=$build_dir PREFIX=$prefix"
+
+    if [ "$mode" = "release" ]; then
+        flags="$flags DEBUG=0"
+    fi
+
+    if [ -n "$cross_triplet" ]; then
+        flags="$flags CC=$cross_triplet-gcc AR=$cross_triplet-ar"
+    fi
+
+    echo $flags
+}
+
+{{ end -}}
 c_compile()
 {
-    if [ ! -d ../$package/build ]; then
-        mkdir ../$package/build
-        (cd ../$package/build && cmake ..)
+{{- if eq .BuildSystemName "meson"}}
+    local flags=""
+
+    if [ "$mode" = "release" ]; then
+        flags="-Ddebug_build=false"
+    fi
+
+    if [ -n "$cross_triplet" -a -e $source_dir/cross/$arch.ini ]; then
+        flags="$flags --cross-file cross/$arch.ini"
+    fi
+
+    if [ ! -d $source_dir/$build_dir ]; then
+        (cd $source_dir && meson setup --prefix $prefix $flags $build_dir) || return -1
     fi
 
-    (cd ../$package/build && make || exit -1)
+    (cd $source_dir && ninja -C $build_dir || exit -1)
+{{- else if eq .BuildSystemName "make"}}
+    (make -C $source_dir $(make_variables) || exit -1)
+{{- else}}
+    local flags="-DCMAKE_INSTALL_PREFIX=$prefix"
+
+    if [ "$mode" = "release" ]; then
+        flags="$flags -DDEBUG=OFF"
+    fi
+
+    if [ -n "$cross_triplet" -a -e $source_dir/cmake/toolchains/$arch.cmake ]; then
+        flags="$flags -DCMAKE_TOOLCHAIN_FILE=../cmake/toolchains/$arch.cmake"
+    fi
+
+    if [ ! -d $source_dir/$build_dir ]; then
+        mkdir $source_dir/$build_dir
+        (cd $source_dir/$build_dir && cmake $flags ..)
+    fi
+
+    (cd $source_dir/$build_dir && make || exit -1)
+{{- end}}
 
     if [ $? != 0 ]; then
         return -1
@@ -99,28 +383,143 @@ compile()
 {
     echo "Compiling..."
 
-    if [ -e ../$package/CMakeLists.txt ]; then
-        c_compile
-    elif [ -e ../$package/Cargo.toml ]; then
+    if [ -e $source_dir/Cargo.toml ]; then
         rust_compile
-    else
+    elif [ -n "$(find $source_dir -maxdepth 3 -name '*.go' -print -quit)" ]; then
         go_compile
+    elif [ -e $source_dir/CMakeLists.txt -o -e $source_dir/meson.build -o \
+           -e $source_dir/Makefile ]; then
+        c_compile
+    else
+        script_compile
     fi
 }
 
+# Gives the MAJOR_VERSION.MINOR_VERSION.RELEASE version from the defines of
+# a C header.
+version_from_header()
+{
+    awk '/define[ \t]+(MAJOR_VERSION|MINOR_VERSION|RELEASE)[ \t]/ { v[$(NF-1)] = $NF }
+         END { print v["MAJOR_VERSION"] "." v["MINOR_VERSION"] "." v["RELEASE"] }' $1
+}
+
 package_version()
 {
-    echo "Get package version here"
+    if [ -e $source_dir/Cargo.toml ]; then
+        sed -n 's/^version *= *"\(.*\)"/\1/p' $source_dir/Cargo.toml | head -1
+        return
+    fi
+{{- if eq .ProjectTypeName "library"}}
+
+    version_from_header $source_dir/include/lib$package.h
+{{- else if eq .ProjectTypeName "application"}}
+
+    version_from_header $source_dir/include/${package}_def.h
+{{- else if eq .ProjectTypeName "script"}}
+
+    sed -n 's/^readonly VERSION="\(.*\)"/\1/p' $source_dir/src/$package
+{{- else if eq .LanguageName "go"}}
+
+    grep -A1 'func plugin_version' $source_dir/plugin.go | \
+        sed -n 's/.*CString("\(.*\)").*/\1/p'
+{{- else}}
+
+    sed -n '/CL_PLUGIN_SET_INFO/ { n; n; s/[^0-9.]//gp }' $source_dir/plugin.c
+{{- end}}
 }
 
+# The release is the number of commits since the last git tag, when the
+# project is versioned with tags, or a local build counter otherwise.
 package_release()
 {
-    echo "Get package release here"
+    local counter=".release"
+    local describe
+
+    if describe=$(git -C ../$package describe --tags --long 2> /dev/null); then
+        echo $describe | awk -F- '{ print $(NF-1) + 1 }'
+        return
+    fi
+
+    local release=$(($(cat $counter 2> /dev/null || echo 0) + 1))
+
+    echo $release > $counter
+    echo $release
 }
 
+# Installs the compiled project inside the package structure, through the
+# install step of its build system.
 copy_package_core_files()
 {
-    echo "Copy the package core files to the package structure"
+{{- if eq .ProjectTypeName "script"}}
+    mkdir -p $tmpdir/usr/bin
+    install -m 0755 $source_dir/src/$package $tmpdir/usr/bin/$package
+{{- else}}
+    local destdir=$(pwd)/$tmpdir
+{{- if eq .LanguageName "go"}}
+
+    make -C $source_dir install DESTDIR=$destdir PREFIX=$prefix
+{{- else if eq .BuildSystemName "meson"}}
+
+    DESTDIR=$destdir meson install -C $source_dir/$build_dir --no-rebuild
+{{- else if eq .BuildSystemName "make"}}
+
+    make -C $source_dir $(make_variables) install DESTDIR=$destdir
+{{- else}}
+
+    make -C $source_dir/$build_dir install DESTDIR=$destdir
+{{- end}}
+{{- end}}
+}
+
+{{- if eq .ProjectTypeName "library"}}
+
+# Moves the development files (headers, static library, unversioned library
+# link, pkg-config and cmake files) out of the runtime package directory.
+split_dev_files()
+{
+    local devdir=$1
+
+    (cd $tmpdir && find . -name '*.h' -o -name '*.a' -o -name '*.pc' \
+        -o -name '*.cmake' -o -name lib$package.so) | while read file; do
+        mkdir -p $devdir/$(dirname $file)
+        mv $tmpdir/$file $devdir/$file
+    done
+
+    find $tmpdir$prefix -depth -type d -empty -delete
+}
+{{- end}}
+
+# Writes the control file of a package directory and builds the .deb file
+# from it.
+create_deb()
+{
+    local dir=$1
+    local name=$2
+    local version=$3
+    local depends=$4
+    local description=$5
+{{- if eq .ProjectTypeName "script"}}
+    local deb_arch=all
+{{- else}}
+    local deb_arch=$dpkg_arch
+{{- end}}
+    local filename=$name-$version-$deb_arch.deb
+
+    cat << CONTROL >> $dir/DEBIAN/control
+Package: $name
+Priority: optional
+Version: $version
+Architecture: $deb_arch
+Maintainer: {{.Author}}
+Description: $description
+CONTROL
+
+    if [ -n "$depends" ]; then
+        echo "Depends: $depends" >> $dir/DEBIAN/control
+    fi
+
+    echo "Building package $filename"
+    fakeroot dpkg-deb -Zgzip -b $dir $filename
 }
 
 build_package()
@@ -128,34 +527,143 @@ build_package()
     local tmpdir="$package-release"
     local version=$(package_version)
     local release=$(package_release)
-    local filename=$package-$version-$release-$arch.deb
-    local depends=""
+    local depends="{{if .ServiceUserName}}adduser{{end}}"
+{{- if eq .ProjectTypeName "library"}}
+    local devdir="$package-dev-release"
+
+    # The runtime package is named after the library soname
+    local name=lib$package${version%%.*}
+
+    if ! grep -qx "Package: $name" $source_dir/debian/control; then
+        echo "debian/control has no $name package, the library soname changed"
+        return -1
+    fi
+{{- else}}
+    local name=$package
+{{- end}}
 
     echo "Copying internal package files..."
-    mkdir -p $tmpdir/{opt/$package,DEBIAN,etc/systemd/system}
-    copy_package_core_files
+    mkdir -p $tmpdir/{opt/$package,DEBIAN}
+    copy_package_core_files || return -1
+{{- if eq .ProjectTypeName "library"}}
+
+    mkdir -p $devdir/DEBIAN
+    split_dev_files $devdir
+{{- end}}
 
     # Copy package and misc files
-    cp default/p* $tmpdir/DEBIAN
-    cp ../misc/*.service $tmpdir/etc/systemd/system
+    cp debian/p* $tmpdir/DEBIAN
+{{- if .ConfigFile}}
 
-    cat << CONTROL >> $tmpdir/DEBIAN/control
-Package: $package
-Priority: optional
-Version: $version-$release
-Architecture: $arch
-Depends: $depends
-Maintainer: {{.Author}}
-Description:
-CONTROL
+    mkdir -p $tmpdir/etc/$package
+    cp misc/$package.conf $tmpdir{{.ConfigFile}}
+    echo {{.ConfigFile}} >> $tmpdir/DEBIAN/conffiles
+{{- end}}
+{{- if .LogFile}}
 
-    echo "Building package $filename"
-    fakeroot dpkg-deb -Zgzip -b $tmpdir $filename
+    mkdir -p $tmpdir/etc/logrotate.d
+    cp misc/$package.logrotate $tmpdir/etc/logrotate.d/$package
+    echo /etc/logrotate.d/$package >> $tmpdir/DEBIAN/conffiles
+{{- end}}
+{{- if .SystemdUnits}}
+
+    mkdir -p $tmpdir/lib/systemd/system
+
+    for unit in {{.SystemdUnits}}; do
+        cp misc/$unit $tmpdir/lib/systemd/system
+    done
+{{- end}}
+
+    create_deb $tmpdir $name $version-$release "$depends" \
+        "The $package {{.ProjectTypeName}}"
+{{- if eq .ProjectTypeName "library"}}
+
+    create_deb $devdir lib$package-dev $version-$release \
+        "$name (= $version-$release)" \
+        "Development files for the $package library"
+
+    rm -rf $devdir
+{{- end}}
 
     rm -rf $tmpdir
 }
 
-while getopts ha:R: opts; do
+# Creates a tarball of the project sources, leaving out its git repository
+# and only the build directories, so files like build.rs are kept.
+create_source_tarball()
+{
+    local tarball=$1
+    local version=$2
+    local srcdir=${source_dir#../}
+
+    tar -czf $tarball --exclude='.git' --anchored \
+        --exclude="$srcdir/build" --exclude="$srcdir/build-*" \
+        --exclude="$srcdir/builddir" --exclude="$package/compile_commands.json" \
+        --transform "s,^$package,$package-$version," -C .. $package
+}
+
+# Creates a <package>-<version>.tar.gz source release, along with its
+# SHA256SUMS, at the current directory.
+source_release()
+{
+{{- if or (eq .ProjectTypeName "library") (eq .ProjectTypeName "application")}}
+    local build_dir="build-dist"
+    local outdir=$source_dir/$build_dir
+    local version=$(package_version)
+
+    echo "Creating source release..."
+{{- if eq .BuildSystemName "meson"}}
+
+    if [ ! -d $source_dir/$build_dir ]; then
+        (cd $source_dir && meson setup $build_dir) || return -1
+    fi
+
+    ninja -C $source_dir/$build_dir tarball || return -1
+{{- else if eq .BuildSystemName "make"}}
+
+    make -C $source_dir BUILDDIR=$build_dir dist || return -1
+{{- else}}
+
+    if [ ! -d $source_dir/$build_dir ]; then
+        mkdir $source_dir/$build_dir
+        (cd $source_dir/$build_dir && cmake ..) || return -1
+    fi
+
+    make -C $source_dir/$build_dir dist || return -1
+{{- end}}
+
+    cp $outdir/$package-$version.tar.gz $outdir/SHA256SUMS .
+{{- else}}
+    local version=$(package_version)
+
+    echo "Creating source release..."
+    create_source_tarball $package-$version.tar.gz $version || return -1
+
+    sha256sum $package-$version.tar.gz > SHA256SUMS
+{{- end}}
+}
+
+# Builds the package with rpmbuild, which also compiles the project, using
+# the spec file from the rpm directory.
+build_rpm_package()
+{
+    local topdir="$(pwd)/rpmbuild"
+    local version=$(awk '/^Version:/ { print $2 }' rpm/$package.spec)
+
+    mkdir -p $topdir/SOURCES
+
+    echo "Creating source tarball..."
+    create_source_tarball $topdir/SOURCES/$package-$version.tar.gz $version || return -1
+
+    echo "Building RPM package"
+    rpmbuild -ba --target $rpm_arch --define "_topdir $topdir" \
+        rpm/$package.spec || return -1
+
+    find $topdir/RPMS $topdir/SRPMS -name '*.rpm' -exec cp {} . \;
+    rm -rf $topdir
+}
+
+while getopts ha:R:st: opts; do
     case $opts in
         h)
             usage
@@ -170,17 +678,43 @@ while getopts ha:R: opts; do
             mode=$OPTARG
             ;;
 
+        s)
+            type="source"
+            ;;
+
+        t)
+            type=$OPTARG
+            ;;
+
         ?)
             exit -1
             ;;
     esac
 done
 
+# Source releases do not depend on the architecture
+if [ "$type" = "source" ]; then
+    source_release || exit -1
+    exit 0
+fi
+
 if [ -z "$arch" -o $(validate_arch) != 0 ]; then
     echo "Unsupported '$arch' architecture!"
     exit -1
 fi
 
+if [ "$type" != "deb" -a "$type" != "rpm" ]; then
+    echo "Unsupported '$type' package type!"
+    exit -1
+fi
+
+set_arch
+
+if [ "$type" = "rpm" ]; then
+    build_rpm_package || exit -1
+    exit 0
+fi
+
 # compile
 compile
 ret=$?
@@ -190,12 +724,13 @@ if [ $ret != 0 ]; then
 fi
 
 # build the package
-build_package
+build_package || exit -1
 
 `
 
 type BashFile struct {
-	content string
+	content   string
+	extension string
 	base.FileOptions
 	ContentData
 }
@@ -204,7 +739,12 @@ func (s BashFile) Header(file *os.File) {
 }
 
 func (s BashFile) HeaderComment(file *os.File) {
-	file.WriteString("#!/bin/bash\n")
+	if s.extension == ".bats" {
+		file.WriteString("#!/usr/bin/env bats\n")
+	} else {
+		file.WriteString("#!/bin/bash\n")
+	}
+
 	tpl, err := BashSourceHeader()
 
 	if err != nil {
@@ -215,6 +755,11 @@ func (s BashFile) HeaderComment(file *os.File) {
 }
 
 func (s BashFile) Footer(file *os.File) {
+	// bats test files are not executed as regular scripts
+	if s.extension == ".bats" {
+		return
+	}
+
 	file.WriteString("\nexit 0\n")
 }
 
@@ -231,7 +776,7 @@ func (s BashFile) Content(file *os.File) {
 
 func NewBash(options base.FileOptions) base.FileTemplate {
 	var content string
-	bname, _ := extractFilename(options.Name, options.ProjectType)
+	bname, extension := extractFilename(options.Name, options.ProjectType)
 
 	if options.ProjectType == base.XantePluginProject {
 		if bname == options.ProjectName {
@@ -239,15 +784,28 @@ func NewBash(options base.FileOptions) base.FileTemplate {
 		}
 	}
 
+	if options.ProjectType == base.ScriptProject {
+		if extension == ".bats" {
+			content = scriptTestContent
+		} else if bname == options.ProjectName {
+			content = scriptContent
+		}
+	}
+
 	if options.PackageProject {
 		if bname == "build-package" {
 			content = packageBuildScriptContent
 		}
+
+		if script, debhelper, ok := isMaintainerScript(bname, extension); ok {
+			content = maintainerContent(script, options, debhelper)
+		}
 	}
 
 	return &BashFile{
 		FileOptions: options,
 		content:     content,
+		extension:   extension,
 		ContentData: GetContentData(options),
 	}
 }
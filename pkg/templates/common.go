@@ -69,6 +69,14 @@ const goHeaderContent = `
 //
 `
 
+// The version every C project starts with, written into its headers. The
+// MAJOR_VERSION is also the soname version of created libraries.
+const (
+	projectMajorVersion = 0
+	projectMinorVersion = 1
+	projectRelease      = 1
+)
+
 // ContentData must be used to replace variables inside template strings.
 type ContentData struct {
 	ProjectName           string
@@ -79,7 +87,24 @@ type ContentData struct {
 	ProjectIncludeFiles   string
 	LibcollectionsInclude string
 	LibcollectionsLinker  string
+	CmockaLinker          string
 	ProjectNameSnaked     string
+	ProjectTypeName       string
+	BuildSystemName       string
+	LanguageName          string
+	Prefix                string
+	SystemdUnits          string
+	EnableUnits           string
+	StartUnits            string
+	ServiceUserName       string
+	ConfigFile            string
+	LogFile               string
+	NotifyService         bool
+	ForkingService        bool
+	SocketActivated       bool
+	MajorVersion          int
+	MinorVersion          int
+	Release               int
 }
 
 func CSourceHeader() (*template.Template, error) {
@@ -151,15 +176,40 @@ func camelCase(src string) string {
 
 func GetContentData(options base.FileOptions) ContentData {
 	now := time.Now()
+	projectType, _ := base.ProjectKey(options.ProjectType)
+	buildSystem, _ := base.BuildSystemKey(options.BuildSystem)
+	language, _ := base.LanguageKey(options.Language)
 
-	return ContentData{
+	data := ContentData{
 		ProjectName:       options.ProjectName,
 		ProjectNameUpper:  strings.ToUpper(options.ProjectName),
 		Author:            options.AuthorName,
 		Year:              now.Year(),
 		Date:              now.Format(time.ANSIC),
 		ProjectNameSnaked: camelCase(options.ProjectName),
+		ProjectTypeName:   projectType,
+		BuildSystemName:   buildSystem,
+		LanguageName:      language,
+		Prefix:            options.InstallPrefix,
+		SystemdUnits:      strings.Join(SystemdUnits(options.ProjectOptions), " "),
+		EnableUnits:       strings.Join(enabledUnits(options.ProjectOptions), " "),
+		StartUnits:        strings.Join(startedUnits(options.ProjectOptions), " "),
+		ServiceUserName:   options.ServiceUser,
+		ConfigFile:        options.ConfigFile(),
+		LogFile:           options.LogFile(),
+		MajorVersion:      projectMajorVersion,
+		MinorVersion:      projectMinorVersion,
+		Release:           projectRelease,
+	}
+
+	// Timer activated services are always oneshot ones
+	if options.HasService() && options.ServiceTimer == "" {
+		data.NotifyService = options.ServiceType == "notify"
+		data.ForkingService = options.ServiceType == "forking"
+		data.SocketActivated = options.ServiceSocket != ""
 	}
+
+	return data
 }
 
 // extractFilename gives only the file name without path and extension.
@@ -176,8 +226,8 @@ func extractFilename(filename string, projectType int) (string, string) {
 }
 
 func errorContent(fileOptions ContentType, options base.FileOptions) string {
-	if options.LibcollectionsFeatures {
-		if fileOptions&Source != 0 {
+	if fileOptions&Source != 0 {
+		if options.LibcollectionsFeatures {
 			return `
 static const char *__description[] = {
     cl_tr_noop("Ok"),
@@ -221,26 +271,51 @@ __PUB_API__ const char *{{.ProjectName}}_strerror(enum {{.ProjectName}}_error_co
     return __description[code];
 }
 `
-		} else {
-			if fileOptions&InternalHeader != 0 {
-				return `
+		}
+
+		return `
+static const char *description[] = {
+    "Ok",
+};
+
+__PUB_API__ const char *{{.ProjectName}}_strerror(enum {{.ProjectName}}_error_code code)
+{
+    if (code >= {{.ProjectNameUpper}}_MAX_ERROR_CODE)
+        return "Unknown error";
+
+    return description[code];
+}
+`
+	}
+
+	// The last error storage is only available with libcollections
+	if fileOptions&InternalHeader != 0 {
+		codes := `
 enum {{.ProjectName}}_error_code {
     {{.ProjectNameUpper}}_NO_ERROR,
 
     {{.ProjectNameUpper}}_MAX_ERROR_CODE
 };
+`
 
+		if options.LibcollectionsFeatures {
+			return codes + `
 void errno_clear(void);
 void errno_set(enum {{.ProjectName}}_error_code code);
 `
-			} else {
-				return `
+		}
+
+		return codes
+	}
+
+	if options.LibcollectionsFeatures {
+		return `
 enum {{.ProjectName}}_error_code {{.ProjectName}}_get_last_error(void);
 const char *{{.ProjectName}}_strerror(enum {{.ProjectName}}_error_code code);
 `
-			}
-		}
 	}
 
-	return ""
+	return `
+const char *{{.ProjectName}}_strerror(enum {{.ProjectName}}_error_code code);
+`
 }
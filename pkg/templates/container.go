@@ -0,0 +1,223 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package templates
+
+import (
+	"os"
+	"strings"
+	"text/template"
+
+	"source-template/pkg/base"
+)
+
+const containerfileContent = `# Builds {{.ProjectName}} and creates a minimal image running it:
+#
+#   podman build -t {{.ProjectName}} -f Containerfile .
+
+FROM debian:bookworm-slim AS build
+
+RUN apt-get update && apt-get install -y --no-install-recommends \
+{{- range .BuildDepends}}
+        {{.}} \
+{{- end}}
+    && rm -rf /var/lib/apt/lists/*
+
+WORKDIR /src
+COPY . .
+
+# Installed with the same layout of the debian package
+{{- if eq .BuildSystemName "meson"}}
+RUN meson setup --prefix /usr -Ddebug_build=false build \
+    && meson compile -C build \
+    && meson install -C build --destdir /staging
+{{- else if eq .BuildSystemName "make"}}
+RUN make DEBUG=0 PREFIX=/usr \
+    && make DEBUG=0 PREFIX=/usr DESTDIR=/staging install
+{{- else}}
+RUN cmake -B build -DCMAKE_INSTALL_PREFIX=/usr -DDEBUG=OFF \
+    && cmake --build build \
+    && DESTDIR=/staging cmake --install build
+{{- end}}
+{{- if .ConfigFile}}
+
+RUN install -D -m 0644 debian/{{.ProjectName}}.conf /staging{{.ConfigFile}}
+{{- end}}
+{{- if .SharedLibraries}}
+
+# Shared libraries not available in the runtime image
+RUN mkdir -p /staging/usr/lib \
+    && ldd /staging/usr/bin/{{.ProjectName}} | \
+        awk '/{{.SharedLibraries}}/ { print $3 }' | \
+        xargs -r -I{} cp -L {} /staging/usr/lib/
+{{- end}}
+
+FROM debian:bookworm-slim
+{{- if .User}}
+
+RUN useradd --system --home-dir /var/lib/{{.ProjectName}} \
+        --shell /usr/sbin/nologin {{.User}} \
+    && mkdir -p /var/lib/{{.ProjectName}} \
+    && chown {{.User}}:{{.User}} /var/lib/{{.ProjectName}}
+{{- else}}
+
+RUN mkdir -p /var/lib/{{.ProjectName}}
+{{- end}}
+
+COPY --from=build /staging/ /
+
+WORKDIR /var/lib/{{.ProjectName}}
+{{- if .User}}
+USER {{.User}}
+{{- end}}
+{{- if .Port}}
+EXPOSE {{.Port}}
+
+# TODO: There is no socket activation inside containers, so the application
+# must listen at port {{.Port}} by itself. Then the port may be probed with:
+#   CMD bash -c 'exec 3<> /dev/tcp/127.0.0.1/{{.Port}}' || exit 1
+{{- else if .SocketPath}}
+
+# TODO: There is no socket activation inside containers, so the application
+# must listen at {{.SocketPath}} by itself. Then the socket may be probed with:
+#   CMD test -S {{.SocketPath}} || exit 1
+{{- else}}
+
+# TODO: Replace with a real probe of the application health
+{{- end}}
+HEALTHCHECK --interval=30s --timeout=5s --retries=3 \
+    CMD kill -0 1 || exit 1
+
+ENTRYPOINT ["/usr/bin/{{.ProjectName}}"{{if .ConfigFile}}, "--config", "{{.ConfigFile}}"{{end}}]
+`
+
+const containerignoreContent = `.git
+build
+build-*
+builddir
+compile_commands.json
+*.o
+*.a
+*.so
+*.so.*
+`
+
+// ContainerData holds what is needed to build a project inside a container
+// image.
+type ContainerData struct {
+	ContentData
+	BuildDepends    []string
+	SharedLibraries string
+	User            string
+	Port            string
+	SocketPath      string
+}
+
+type ContainerFile struct {
+	content string
+	base.FileOptions
+	ContainerData
+}
+
+func (c ContainerFile) Header(file *os.File) {
+}
+
+func (c ContainerFile) HeaderComment(file *os.File) {
+}
+
+func (c ContainerFile) Footer(file *os.File) {
+}
+
+func (c ContainerFile) Content(file *os.File) {
+	tpl, err := template.New("container").Parse(c.content)
+
+	if err != nil {
+		return
+	}
+
+	tpl.Execute(file, c.ContainerData)
+}
+
+// socketPort gives the TCP port of a socket address (8080, 0.0.0.0:8080 or
+// [::]:8080), or an empty string when it is not a TCP address.
+func socketPort(address string) string {
+	port := address[strings.LastIndex(address, ":")+1:]
+
+	if port == "" || strings.Trim(port, "0123456789") != "" {
+		return ""
+	}
+
+	return port
+}
+
+func getContainerData(options base.FileOptions) ContainerData {
+	var libraries []string
+	data := ContainerData{
+		ContentData:  GetContentData(options),
+		BuildDepends: []string{"gcc", "libc6-dev"},
+		User:         options.ServiceUser,
+		Port:         socketPort(options.ServiceSocket),
+	}
+
+	if data.Port == "" && strings.HasPrefix(options.ServiceSocket, "/") {
+		data.SocketPath = options.ServiceSocket
+	}
+
+	switch options.BuildSystem {
+	case base.MesonBuildSystem:
+		data.BuildDepends = append(data.BuildDepends, "meson", "ninja-build")
+
+	case base.MakeBuildSystem:
+		data.BuildDepends = append(data.BuildDepends, "make")
+
+	default:
+		data.BuildDepends = append(data.BuildDepends, "cmake", "make")
+	}
+
+	if options.LibcollectionsFeatures {
+		data.BuildDepends = append(data.BuildDepends, "libcollections-dev")
+		libraries = append(libraries, "libcollections")
+	}
+
+	if options.CmockaTests {
+		data.BuildDepends = append(data.BuildDepends, "libcmocka-dev")
+	}
+
+	data.SharedLibraries = strings.Join(libraries, "|")
+
+	return data
+}
+
+// NewContainer creates the template of the Containerfile building a project
+// image, or of its .containerignore.
+func NewContainer(options base.FileOptions) base.FileTemplate {
+	var content string
+
+	switch options.Name {
+	case "Containerfile":
+		content = containerfileContent
+
+	case ".containerignore":
+		content = containerignoreContent
+	}
+
+	return &ContainerFile{
+		FileOptions:   options,
+		content:       content,
+		ContainerData: getContainerData(options),
+	}
+}
@@ -0,0 +1,377 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package templates
+
+import (
+	"os"
+	"strconv"
+	"text/template"
+	"time"
+
+	"source-template/pkg/base"
+)
+
+const debianControlContent = `Source: {{.ProjectName}}
+Section: {{.Section}}
+Priority: optional
+Maintainer: {{.Author}} <{{.Email}}>
+Build-Depends: {{.BuildDepends}}
+Standards-Version: 4.6.2
+Rules-Requires-Root: no
+
+{{- range .Packages}}
+
+Package: {{.Name}}
+{{- if .Section}}
+Section: {{.Section}}
+{{- end}}
+Architecture: {{.Architecture}}
+{{- if .MultiArch}}
+Multi-Arch: {{.MultiArch}}
+{{- end}}
+Depends: {{.Depends}}
+Description: {{.Summary}}
+ Replace this text with a longer description of {{$.ProjectName}}.
+{{- end}}
+`
+
+const debianChangelogContent = `{{.ProjectName}} (0.1.1) unstable; urgency=medium
+
+  * Initial release.
+
+ -- {{.Author}} <{{.Email}}>  {{.ChangelogDate}}
+`
+
+const debianRulesContent = `#!/usr/bin/make -f
+
+export DEB_BUILD_MAINT_OPTIONS = hardening=+all
+export DEB_HOST_MULTIARCH ?= $(shell dpkg-architecture -qDEB_HOST_MULTIARCH)
+{{- if eq .LanguageName "go"}}
+export GOCACHE = $(CURDIR)/debian/.gocache
+{{- end}}
+{{- if eq .ProjectTypeName "library"}}
+
+# The runtime package is named after the library soname, so debian/control
+# and the debian/lib{{.ProjectName}}N.* files must be renamed when the
+# MAJOR_VERSION of the library header changes.
+SONAME_PACKAGE := lib{{.ProjectName}}$(shell awk '/define/ && $$(NF-1) == "MAJOR_VERSION" { print $$NF }' include/lib{{.ProjectName}}.h)
+
+ifeq (,$(shell grep -x 'Package: $(SONAME_PACKAGE)' debian/control))
+$(error debian/control has no $(SONAME_PACKAGE) package, the library soname changed)
+endif
+{{- end}}
+
+%:
+	dh $@{{.DhOptions}}
+{{- if eq .DhBuildSystem ""}}
+
+override_dh_auto_build:
+	bash -n src/{{.ProjectName}}
+
+override_dh_auto_test:
+ifeq (,$(filter nocheck,$(DEB_BUILD_OPTIONS)))
+	if command -v bats > /dev/null; then bats tests; fi
+endif
+{{- else}}
+{{- if eq .DhBuildSystem "cmake"}}
+
+override_dh_auto_configure:
+	dh_auto_configure -- -DDEBUG=OFF
+{{- else if eq .DhBuildSystem "meson"}}
+
+override_dh_auto_configure:
+	dh_auto_configure -- -Ddebug_build=false
+{{- else}}
+
+override_dh_auto_build:
+	dh_auto_build -- {{.MakeVariables}}
+{{- end}}
+
+override_dh_auto_install:
+	dh_auto_install --destdir=debian/tmp{{if eq .DhBuildSystem "makefile"}} -- {{.MakeVariables}}{{end}}
+{{- end}}
+`
+
+const debianCopyrightContent = `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+Upstream-Name: {{.ProjectName}}
+Upstream-Contact: {{.Author}} <{{.Email}}>
+
+Files: *
+Copyright: {{.Year}} {{.Author}}
+License: proprietary
+ All rights reserved.
+`
+
+const debianSourceFormatContent = `3.0 (native)
+`
+
+const debianInstallContent = `{{range .InstallFiles}}{{.}}
+{{end}}`
+
+// DebianPackage is one of the binary packages built from a debian source
+// package.
+type DebianPackage struct {
+	Name         string
+	Section      string
+	Architecture string
+	MultiArch    string
+	Depends      string
+	Summary      string
+	InstallFiles []string
+}
+
+// DebianData holds what is needed to describe a project as a debian source
+// package.
+type DebianData struct {
+	ContentData
+	Email         string
+	Section       string
+	BuildDepends  string
+	ChangelogDate string
+	DhBuildSystem string
+	DhOptions     string
+	MakeVariables string
+	Packages      []DebianPackage
+	InstallFiles  []string
+}
+
+type DebianFile struct {
+	content string
+	base.FileOptions
+	DebianData
+}
+
+func (d DebianFile) Header(file *os.File) {
+}
+
+func (d DebianFile) HeaderComment(file *os.File) {
+}
+
+func (d DebianFile) Footer(file *os.File) {
+}
+
+func (d DebianFile) Content(file *os.File) {
+	tpl, err := template.New("debian").Parse(d.content)
+
+	if err != nil {
+		return
+	}
+
+	tpl.Execute(file, d.DebianData)
+}
+
+// debhelperBuildSystem gives the debhelper build system able to build a
+// project, or an empty string when it has nothing to build.
+func debhelperBuildSystem(options base.FileOptions) string {
+	if options.ProjectType == base.ScriptProject {
+		return ""
+	}
+
+	if options.Language == base.GoLanguage {
+		return "makefile"
+	}
+
+	switch options.BuildSystem {
+	case base.MesonBuildSystem:
+		return "meson"
+
+	case base.MakeBuildSystem:
+		return "makefile"
+	}
+
+	return "cmake"
+}
+
+// debianPackages gives the binary packages of a project. Libraries are split
+// into a runtime package, named after their soname (the MAJOR_VERSION of their
+// main header), and a -dev package.
+func debianPackages(options base.ProjectOptions) []DebianPackage {
+	multiarchLib := "usr/lib/${DEB_HOST_MULTIARCH}"
+	name := options.ProjectName
+	pkg := DebianPackage{
+		Name:         name,
+		Architecture: "any",
+		Depends:      "${shlibs:Depends}, ${misc:Depends}",
+	}
+
+	switch options.ProjectType {
+	case base.ApplicationProject:
+		pkg.Summary = "The " + name + " application"
+		pkg.InstallFiles = []string{"usr/bin/" + name}
+
+		if options.Config {
+			pkg.InstallFiles = append(pkg.InstallFiles,
+				"debian/"+name+".conf etc/"+name)
+		}
+
+	case base.LibraryProject:
+		dev := DebianPackage{
+			Name:         "lib" + name + "-dev",
+			Section:      "libdevel",
+			Architecture: "any",
+			MultiArch:    "same",
+			Summary:      "Development files for the " + name + " library",
+			InstallFiles: []string{
+				multiarchLib + "/lib" + name + ".so",
+				multiarchLib + "/lib" + name + ".a",
+				multiarchLib + "/pkgconfig",
+				"usr/include/" + name,
+			},
+		}
+
+		pkg.Name = "lib" + name + strconv.Itoa(projectMajorVersion)
+		pkg.MultiArch = "same"
+		pkg.Summary = "The " + name + " library"
+		pkg.InstallFiles = []string{multiarchLib + "/lib" + name + ".so.*"}
+		dev.Depends = pkg.Name + " (= ${binary:Version}), ${misc:Depends}"
+
+		if options.BuildSystem == base.CMakeBuildSystem {
+			dev.InstallFiles = append(dev.InstallFiles, multiarchLib+"/cmake/"+name)
+		}
+
+		if options.LibcollectionsFeatures {
+			dev.Depends += ", libcollections-dev"
+		}
+
+		return []DebianPackage{pkg, dev}
+
+	case base.XantePluginProject:
+		pkg.Summary = "The " + name + " xante plugin"
+		pkg.InstallFiles = []string{multiarchLib + "/" + name + ".so"}
+
+	case base.ScriptProject:
+		pkg.Architecture = "all"
+		pkg.Depends = "${misc:Depends}, bash"
+		pkg.Summary = "The " + name + " script"
+		pkg.InstallFiles = []string{"src/" + name + " usr/bin"}
+	}
+
+	// The service user is created by the maintainer scripts
+	if options.HasService() && options.ServiceUser != "" {
+		pkg.Depends += ", adduser"
+	}
+
+	return []DebianPackage{pkg}
+}
+
+func getDebianData(options base.FileOptions) DebianData {
+	data := DebianData{
+		ContentData:   GetContentData(options),
+		Email:         options.Email,
+		Section:       "utils",
+		BuildDepends:  "debhelper-compat (= 13)",
+		Packages:      debianPackages(options.ProjectOptions),
+		ChangelogDate: time.Now().Format(time.RFC1123Z),
+		DhBuildSystem: debhelperBuildSystem(options),
+		MakeVariables: "DEBUG=0 PREFIX=/usr LIBDIR=/usr/lib/$(DEB_HOST_MULTIARCH)",
+	}
+
+	switch data.DhBuildSystem {
+	case "cmake":
+		data.BuildDepends += ", cmake"
+
+	case "meson":
+		data.BuildDepends += ", meson, ninja-build"
+	}
+
+	if data.DhBuildSystem != "" {
+		data.DhOptions = " --buildsystem=" + data.DhBuildSystem
+	}
+
+	if options.LibcollectionsFeatures {
+		data.BuildDepends += ", libcollections-dev"
+	}
+
+	if options.CmockaTests {
+		data.BuildDepends += ", libcmocka-dev"
+	}
+
+	switch options.ProjectType {
+	case base.LibraryProject:
+		data.Section = "libs"
+
+	case base.XantePluginProject:
+		data.Section = "misc"
+		data.DhOptions += " --sourcedirectory=src"
+
+		if options.Language == base.GoLanguage {
+			data.BuildDepends += ", golang-go"
+			data.MakeVariables = "PREFIX=/usr LIBDIR=/usr/lib/$(DEB_HOST_MULTIARCH)"
+		} else {
+			data.BuildDepends += ", libxante-dev"
+
+			// Already required by libcollections features
+			if !options.LibcollectionsFeatures {
+				data.BuildDepends += ", libcollections-dev"
+			}
+		}
+	}
+
+	return data
+}
+
+// DebianPackageNames gives the name of all binary packages of a project. The
+// first one holds the project itself, and its maintainer scripts.
+func DebianPackageNames(options base.ProjectOptions) []string {
+	var names []string
+
+	for _, pkg := range debianPackages(options) {
+		names = append(names, pkg.Name)
+	}
+
+	return names
+}
+
+// NewDebian creates a template for one of the files of the debian directory,
+// which turns the project into a debian source package.
+func NewDebian(options base.FileOptions) base.FileTemplate {
+	var content string
+
+	switch options.Name {
+	case "control":
+		content = debianControlContent
+
+	case "changelog":
+		content = debianChangelogContent
+
+	case "rules":
+		content = debianRulesContent
+
+	case "copyright":
+		content = debianCopyrightContent
+
+	case "source/format":
+		content = debianSourceFormatContent
+	}
+
+	data := getDebianData(options)
+
+	// Each binary package has its own <package>.install file
+	for _, pkg := range data.Packages {
+		if options.Name == pkg.Name+".install" {
+			content = debianInstallContent
+			data.InstallFiles = pkg.InstallFiles
+		}
+	}
+
+	return &DebianFile{
+		FileOptions: options,
+		content:     content,
+		DebianData:  data,
+	}
+}
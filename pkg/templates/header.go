@@ -83,9 +83,9 @@ func applicationMainHeaderContent(name string, projectType int) string {
 {{.LibcollectionsInclude}}
 
 #ifdef LIB%[1]s_COMPILE
-# define MAJOR_VERSION		0
-# define MINOR_VERSION		1
-# define RELEASE			1
+# define MAJOR_VERSION		{{.MajorVersion}}
+# define MINOR_VERSION		{{.MinorVersion}}
+# define RELEASE			{{.Release}}
 
 # include "internal/internal.h"
 #endif
@@ -98,6 +98,22 @@ func applicationMainHeaderContent(name string, projectType int) string {
 #include <stdlib.h>
 #include <unistd.h>
 #include <stdbool.h>
+{{- if or .ConfigFile .NotifyService}}
+#include <string.h>
+{{- end}}
+{{- if .NotifyService}}
+#include <stddef.h>
+{{- end}}
+{{- if .LogFile}}
+#include <fcntl.h>
+{{- end}}
+{{- if or .ConfigFile .LogFile}}
+#include <getopt.h>
+{{- end}}
+{{- if .NotifyService}}
+#include <sys/socket.h>
+#include <sys/un.h>
+{{- end}}
 
 /* External library headers */
 {{.LibcollectionsInclude}}
@@ -131,15 +147,26 @@ const internalLibraryHeaderContent = `
 /* Internal library API */
 {{.ProjectIncludeFiles}}`
 
+const utilsHeaderContent = `
+const char *{{.ProjectName}}_version(void);
+`
+
 const applicationDefines = `
-#define MAJOR_VERSION			0
-#define MINOR_VERSION			1
-#define RELEASE					1
+#define MAJOR_VERSION			{{.MajorVersion}}
+#define MINOR_VERSION			{{.MinorVersion}}
+#define RELEASE					{{.Release}}
 #define BETA					true
 
 #define APP_NAME				"{{.ProjectName}}"
 `
 
+const applicationStructs = `{{if .ConfigFile}}
+/* Settings loaded from the configuration file */
+struct {{.ProjectName}}_config {
+    char log_level[32];
+};
+{{end}}`
+
 const pluginHeaderContent = `
 /* External libraries */
 #include <collections.h>
@@ -179,10 +206,20 @@ func NewHeader(options base.FileOptions, sources []string) base.FileTemplate {
 		}
 
 		content = errorContent(flags, options)
+	} else if bname == "utils" && options.ProjectType == base.LibraryProject {
+		dir = filepath.Base(filepath.Dir(options.Name))
+
+		if dir == "api" {
+			content = utilsHeaderContent
+		}
 	} else if strings.Contains(bname, "_def") {
 		content = applicationDefines
+	} else if strings.HasSuffix(bname, "_struct") {
+		content = applicationStructs
 	} else if bname == "plugin" {
 		content = pluginHeaderContent
+	} else if bname == "test_assert" {
+		content = testAssertHeaderContent
 	}
 
 	contentData := GetContentData(options)
@@ -0,0 +1,228 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"source-template/pkg/base"
+)
+
+// Maintainer script snippets. The systemd and ldconfig ones are left out of
+// the debhelper scripts, since it generates them by itself.
+const addUserSnippet = `
+        if ! getent group {{.ServiceUserName}} > /dev/null; then
+            addgroup --system {{.ServiceUserName}}
+        fi
+
+        if ! getent passwd {{.ServiceUserName}} > /dev/null; then
+            adduser --system --ingroup {{.ServiceUserName}} --no-create-home \
+                --home /var/lib/{{.ProjectName}} --shell /usr/sbin/nologin \
+                {{.ServiceUserName}}
+        fi
+`
+
+const delUserSnippet = `
+        if getent passwd {{.ServiceUserName}} > /dev/null; then
+            deluser --system {{.ServiceUserName}} || true
+        fi
+
+        if getent group {{.ServiceUserName}} > /dev/null; then
+            delgroup --system {{.ServiceUserName}} || true
+        fi
+`
+
+const stateDirSnippet = `
+        install -d -m 0750{{if .ServiceUserName}} -o {{.ServiceUserName}} -g {{.ServiceUserName}}{{end}} /var/lib/{{.ProjectName}}
+`
+
+const logDirSnippet = `
+        install -d -m 0750{{if .ServiceUserName}} -o {{.ServiceUserName}} -g {{.ServiceUserName}}{{end}} /var/log/{{.ProjectName}}
+`
+
+const purgeSnippet = `
+        rm -rf /var/lib/{{.ProjectName}} /etc/{{.ProjectName}}{{if .LogFile}} /var/log/{{.ProjectName}}{{end}}
+`
+
+const ldconfigSnippet = `
+        ldconfig
+`
+
+const startUnitsSnippet = `
+        if command -v systemctl > /dev/null; then
+            systemctl enable {{.EnableUnits}} > /dev/null || true
+        fi
+
+        if [ -d /run/systemd/system ]; then
+            systemctl daemon-reload
+            systemctl restart {{.StartUnits}}
+        fi
+`
+
+const stopUnitsSnippet = `
+        if [ -d /run/systemd/system ]; then
+            systemctl stop {{.SystemdUnits}} || true
+        fi
+`
+
+const disableUnitsSnippet = `
+        if command -v systemctl > /dev/null; then
+            systemctl disable {{.EnableUnits}} > /dev/null || true
+        fi
+`
+
+const reloadUnitsSnippet = `
+        if [ -d /run/systemd/system ]; then
+            systemctl daemon-reload
+        fi
+`
+
+// maintainerAction is a case of a maintainer script, executed for some of the
+// actions dpkg passes as its first argument.
+type maintainerAction struct {
+	actions  string
+	snippets []string
+}
+
+// isMaintainerScript tells if a file is a debian maintainer script, and if
+// it is the one used by debhelper (named <package>.<script>).
+func isMaintainerScript(bname, extension string) (string, bool, bool) {
+	scripts := map[string]bool{
+		"preinst":  true,
+		"prerm":    true,
+		"postinst": true,
+		"postrm":   true,
+	}
+
+	if extension == "" && scripts[bname] {
+		return bname, false, true
+	}
+
+	if script := strings.TrimPrefix(extension, "."); scripts[script] {
+		return script, true, true
+	}
+
+	return "", false, false
+}
+
+func maintainerActions(script string, options base.FileOptions, debhelper bool) []maintainerAction {
+	var configure, remove, purge, stop []string
+	service := options.HasService()
+	library := options.ProjectType == base.LibraryProject
+
+	if service {
+		if options.ServiceUser != "" {
+			configure = append(configure, addUserSnippet)
+			purge = append(purge, delUserSnippet)
+		}
+
+		configure = append(configure, stateDirSnippet)
+
+		if options.LogRotate {
+			configure = append(configure, logDirSnippet)
+		}
+
+		purge = append(purge, purgeSnippet)
+	}
+
+	if !debhelper {
+		if library {
+			configure = append(configure, ldconfigSnippet)
+			remove = append(remove, ldconfigSnippet)
+		}
+
+		if service {
+			configure = append(configure, startUnitsSnippet)
+			stop = append(stop, stopUnitsSnippet)
+			remove = append(remove, reloadUnitsSnippet)
+			purge = append(purge, reloadUnitsSnippet)
+		}
+	}
+
+	switch script {
+	case "preinst":
+		return []maintainerAction{
+			{actions: "install|upgrade|abort-upgrade"},
+		}
+
+	case "postinst":
+		return []maintainerAction{
+			{actions: "configure", snippets: configure},
+			{actions: "abort-upgrade|abort-remove|abort-deconfigure"},
+		}
+
+	case "prerm":
+		var disable []string
+
+		if service && !debhelper {
+			disable = append(disable, stopUnitsSnippet, disableUnitsSnippet)
+		}
+
+		return []maintainerAction{
+			{actions: "remove", snippets: disable},
+			{actions: "upgrade|deconfigure", snippets: stop},
+			{actions: "failed-upgrade"},
+		}
+
+	case "postrm":
+		return []maintainerAction{
+			{actions: "remove", snippets: remove},
+			{actions: "purge", snippets: purge},
+			{actions: "upgrade|failed-upgrade|abort-install|abort-upgrade|disappear"},
+		}
+	}
+
+	return nil
+}
+
+// maintainerContent builds the content of a debian maintainer script, which
+// handles every action dpkg may call it with. Only the debhelper scripts get
+// the #DEBHELPER# token, the other ones go straight into dpkg-deb.
+func maintainerContent(script string, options base.FileOptions, debhelper bool) string {
+	var cnt strings.Builder
+
+	cnt.WriteString("\nset -e\n\ncase \"$1\" in\n")
+
+	for _, action := range maintainerActions(script, options, debhelper) {
+		cnt.WriteString(fmt.Sprintf("    %s)\n", action.actions))
+
+		for i, snippet := range action.snippets {
+			if i > 0 {
+				cnt.WriteString("\n")
+			}
+
+			cnt.WriteString(strings.TrimPrefix(snippet, "\n"))
+		}
+
+		cnt.WriteString("        ;;\n\n")
+	}
+
+	cnt.WriteString(fmt.Sprintf(`    *)
+        echo "%s called with unknown argument '$1'" >&2
+        exit 1
+        ;;
+esac
+`, script))
+
+	if debhelper {
+		cnt.WriteString("\n#DEBHELPER#\n")
+	}
+
+	return cnt.String()
+}
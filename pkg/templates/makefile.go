@@ -19,151 +19,430 @@ package templates
 
 import (
 	"os"
+	"strings"
 	"text/template"
 
 	"source-template/pkg/base"
 )
 
-const libContent = `cmake_minimum_required(VERSION 2.8)
-project({{.ProjectName}})
+const libContent = `cmake_minimum_required(VERSION 3.21)
 
-# Options
-option(DEBUG "Enable/Disable debug library" ON)
-option(SHARED "Enable/Disable the shared library version" ON)
+# The library version is kept inside its main header
+set(LIBRARY_HEADER ${CMAKE_CURRENT_SOURCE_DIR}/include/lib{{.ProjectName}}.h)
 
-include_directories(include)
-include_directories("include/api")
-include_directories("include/internal")
+foreach(COMPONENT MAJOR_VERSION MINOR_VERSION RELEASE)
+    file(STRINGS ${LIBRARY_HEADER} DEFINE REGEX "define[ \t]+${COMPONENT}[ \t]")
+    string(REGEX REPLACE ".*${COMPONENT}[ \t]+([0-9]+).*" "\\1" ${COMPONENT} "${DEFINE}")
+endforeach()
 
-if(CMAKE_C_COMPILER_VERSION VERSION_GREATER 5)
-    add_definitions(-fgnu89-inline)
+project({{.ProjectName}}
+    VERSION ${MAJOR_VERSION}.${MINOR_VERSION}.${RELEASE}
+    LANGUAGES C)
+
+# Default installation prefix, which may still be changed with
+# -DCMAKE_INSTALL_PREFIX
+if(CMAKE_INSTALL_PREFIX_INITIALIZED_TO_DEFAULT)
+    set(CMAKE_INSTALL_PREFIX "{{.Prefix}}" CACHE PATH "Installation prefix" FORCE)
 endif()
 
+include(GNUInstallDirs)
+include(CMakePackageConfigHelpers)
+
+set(CMAKE_EXPORT_COMPILE_COMMANDS ON)
+
+# Links the compilation database of the last configured build directory into
+# the project root, where .clangd looks for it
+file(CREATE_LINK ${CMAKE_BINARY_DIR}/compile_commands.json
+     ${CMAKE_SOURCE_DIR}/compile_commands.json SYMBOLIC)
+
+# Options
+option(DEBUG "Enable/Disable debug library" ON)
+option(COVERAGE "Enable/Disable code coverage instrumentation" OFF)
+set(SANITIZE "" CACHE STRING "Sanitizers to enable (e.g. address;undefined)")
+
 if(DEBUG)
     set(CMAKE_BUILD_TYPE Debug)
-else(DEBUG)
+else()
     set(CMAKE_BUILD_TYPE Release)
-endif(DEBUG)
+endif()
 
-add_definitions("-Wall -Wextra -fPIC")
-add_definitions("-DLIB{{.ProjectNameUpper}}_COMPILE -D_GNU_SOURCE")
+if(SANITIZE)
+    string(REPLACE ";" "," SANITIZERS "${SANITIZE}")
+    add_compile_options(-fsanitize=${SANITIZERS} -fno-omit-frame-pointer)
+    add_link_options(-fsanitize=${SANITIZERS})
+endif()
 
-file(GLOB SOURCES "src/*.c")
+if(COVERAGE)
+    add_compile_options(--coverage -O0)
+    add_link_options(--coverage)
+endif()
 
-set(SOURCE
-    ${SOURCES})
+set(VERSION_SCRIPT ${CMAKE_CURRENT_SOURCE_DIR}/misc/lib${PROJECT_NAME}.sym)
+set(CONFIG_INSTALL_DIR ${CMAKE_INSTALL_LIBDIR}/cmake/${PROJECT_NAME})
 
-set(VERSION_SCRIPT
-    ${CMAKE_CURRENT_SOURCE_DIR}/misc/lib${PROJECT_NAME}.sym)
+file(GLOB SOURCES CONFIGURE_DEPENDS "src/*.c")
 
-set(LIBRARY_HEADER
-    ${CMAKE_CURRENT_SOURCE_DIR}/include/lib${PROJECT_NAME}.h)
+# Both library variants are built from the same objects
+add_library(${PROJECT_NAME}_objects OBJECT ${SOURCES})
+set_target_properties(${PROJECT_NAME}_objects PROPERTIES POSITION_INDEPENDENT_CODE ON)
 
-execute_process(COMMAND grep MAJOR_VERSION ${LIBRARY_HEADER}
-    COMMAND awk "{print $4}"
-    COMMAND tr "\n" " "
-    COMMAND sed "s/ //"
-    OUTPUT_VARIABLE MAJOR_VERSION)
+target_include_directories(${PROJECT_NAME}_objects PRIVATE
+    ${CMAKE_CURRENT_SOURCE_DIR}/include
+    ${CMAKE_CURRENT_SOURCE_DIR}/include/api
+    ${CMAKE_CURRENT_SOURCE_DIR}/include/internal)
 
-execute_process(COMMAND grep MINOR_VERSION ${LIBRARY_HEADER}
-    COMMAND awk "{print $4}"
-    COMMAND tr "\n" " "
-    COMMAND sed "s/ //"
-    OUTPUT_VARIABLE MINOR_VERSION)
+target_compile_definitions(${PROJECT_NAME}_objects PRIVATE
+    LIB{{.ProjectNameUpper}}_COMPILE
+    _GNU_SOURCE)
 
-execute_process(COMMAND grep RELEASE ${LIBRARY_HEADER}
-    COMMAND awk "{print $4}"
-    COMMAND tr "\n" " "
-    COMMAND sed "s/ //"
-    OUTPUT_VARIABLE RELEASE)
+target_compile_options(${PROJECT_NAME}_objects PRIVATE -Wall -Wextra)
 
-set(DESTINATION_BIN_DIR "/usr/local/lib")
-set(DESTINATION_HEADER_DIR "/usr/local/include")
+if(CMAKE_C_COMPILER_VERSION VERSION_GREATER 5)
+    target_compile_options(${PROJECT_NAME}_objects PRIVATE -fgnu89-inline)
+endif()
 
-link_directories(${DESTINATION_BIN_DIR})
+add_library(${PROJECT_NAME} SHARED $<TARGET_OBJECTS:${PROJECT_NAME}_objects>)
+add_library(${PROJECT_NAME}_static STATIC $<TARGET_OBJECTS:${PROJECT_NAME}_objects>)
 
-if(SHARED)
-    add_library(${PROJECT_NAME} SHARED ${SOURCE})
-    target_link_libraries(${PROJECT_NAME} collections)
-    set(LIB_VERSION ${MAJOR_VERSION}.${MINOR_VERSION}.${RELEASE})
-    set_target_properties(${PROJECT_NAME} PROPERTIES VERSION ${LIB_VERSION}
-        SOVERSION ${MAJOR_VERSION})
+set_target_properties(${PROJECT_NAME} PROPERTIES
+    VERSION ${PROJECT_VERSION}
+    SOVERSION ${PROJECT_VERSION_MAJOR}
+    LINK_DEPENDS ${VERSION_SCRIPT})
+
+target_link_options(${PROJECT_NAME} PRIVATE
+    "LINKER:--version-script,${VERSION_SCRIPT}")
+
+set_target_properties(${PROJECT_NAME}_static PROPERTIES
+    OUTPUT_NAME ${PROJECT_NAME}
+    POSITION_INDEPENDENT_CODE ON)
+
+foreach(TARGET ${PROJECT_NAME} ${PROJECT_NAME}_static)
+    add_library(${PROJECT_NAME}::${TARGET} ALIAS ${TARGET})
+    target_include_directories(${TARGET} PUBLIC
+        $<BUILD_INTERFACE:${CMAKE_CURRENT_SOURCE_DIR}/include>
+        $<INSTALL_INTERFACE:${CMAKE_INSTALL_INCLUDEDIR}/${PROJECT_NAME}>)
+{{- if .LibcollectionsLinker}}
+
+    target_link_libraries(${TARGET} PRIVATE {{.LibcollectionsLinker}})
+{{- end}}
+endforeach()
+
+# Unit tests, which may also run under valgrind with 'ctest -T memcheck'
+find_program(MEMORYCHECK_COMMAND valgrind)
+set(MEMORYCHECK_COMMAND_OPTIONS "--leak-check=full --error-exitcode=1")
+include(CTest)
+
+file(GLOB TEST_SOURCES CONFIGURE_DEPENDS "tests/test_*.c")
+
+foreach(TEST_SOURCE ${TEST_SOURCES})
+    get_filename_component(TEST_NAME ${TEST_SOURCE} NAME_WE)
+    add_executable(${TEST_NAME} ${TEST_SOURCE})
+    target_compile_definitions(${TEST_NAME} PRIVATE
+        LIB{{.ProjectNameUpper}}_COMPILE
+        _GNU_SOURCE)
+
+    target_link_libraries(${TEST_NAME} PRIVATE ${PROJECT_NAME}{{if .CmockaLinker}} {{.CmockaLinker}}{{end}})
+    add_test(NAME ${TEST_NAME} COMMAND ${TEST_NAME})
+endforeach()
+
+# HTML coverage report, built with 'make coverage'
+if(COVERAGE)
+    find_program(LCOV lcov)
+    find_program(GENHTML genhtml)
+
+    add_custom_target(coverage
+        COMMAND ${CMAKE_CTEST_COMMAND} --output-on-failure
+        COMMAND ${LCOV} --capture --directory . --output-file coverage.info
+        COMMAND ${LCOV} --remove coverage.info "/usr/*" "*/tests/*"
+            --output-file coverage.info
+        COMMAND ${GENHTML} coverage.info --output-directory coverage
+        WORKING_DIRECTORY ${CMAKE_BINARY_DIR}
+        COMMENT "Creating the coverage report at ${CMAKE_BINARY_DIR}/coverage")
+endif()
 
-    set_target_properties(${PROJECT_NAME} PROPERTIES
-                          LINK_FLAGS "-Wl,--version-script,${VERSION_SCRIPT}")
+# Installation, with both ${PROJECT_NAME}::${PROJECT_NAME} and
+# ${PROJECT_NAME}::${PROJECT_NAME}_static available to find_package() users
+install(TARGETS ${PROJECT_NAME} ${PROJECT_NAME}_static
+    EXPORT ${PROJECT_NAME}Targets
+    LIBRARY DESTINATION ${CMAKE_INSTALL_LIBDIR}
+    ARCHIVE DESTINATION ${CMAKE_INSTALL_LIBDIR}
+    RUNTIME DESTINATION ${CMAKE_INSTALL_BINDIR})
+
+install(FILES ${LIBRARY_HEADER}
+    DESTINATION ${CMAKE_INSTALL_INCLUDEDIR}/${PROJECT_NAME})
+
+install(DIRECTORY ${CMAKE_CURRENT_SOURCE_DIR}/include/api
+    DESTINATION ${CMAKE_INSTALL_INCLUDEDIR}/${PROJECT_NAME})
+
+# pkg-config support
+set(prefix ${CMAKE_INSTALL_PREFIX})
+set(libdir ${CMAKE_INSTALL_FULL_LIBDIR})
+set(includedir ${CMAKE_INSTALL_FULL_INCLUDEDIR})
+set(version ${PROJECT_VERSION})
+
+configure_file(misc/lib${PROJECT_NAME}.pc.in
+    ${CMAKE_CURRENT_BINARY_DIR}/lib${PROJECT_NAME}.pc @ONLY)
+
+install(FILES ${CMAKE_CURRENT_BINARY_DIR}/lib${PROJECT_NAME}.pc
+    DESTINATION ${CMAKE_INSTALL_LIBDIR}/pkgconfig)
+
+# find_package() support
+install(EXPORT ${PROJECT_NAME}Targets
+    NAMESPACE ${PROJECT_NAME}::
+    DESTINATION ${CONFIG_INSTALL_DIR})
+
+configure_package_config_file(cmake/${PROJECT_NAME}Config.cmake.in
+    ${CMAKE_CURRENT_BINARY_DIR}/${PROJECT_NAME}Config.cmake
+    INSTALL_DESTINATION ${CONFIG_INSTALL_DIR})
+
+write_basic_package_version_file(
+    ${CMAKE_CURRENT_BINARY_DIR}/${PROJECT_NAME}ConfigVersion.cmake
+    COMPATIBILITY SameMajorVersion)
+
+install(FILES
+    ${CMAKE_CURRENT_BINARY_DIR}/${PROJECT_NAME}Config.cmake
+    ${CMAKE_CURRENT_BINARY_DIR}/${PROJECT_NAME}ConfigVersion.cmake
+    DESTINATION ${CONFIG_INSTALL_DIR})
+
+# Source release tarball and its checksum, built with 'make dist'
+set(CPACK_SOURCE_GENERATOR TGZ)
+set(CPACK_SOURCE_PACKAGE_FILE_NAME ${PROJECT_NAME}-${PROJECT_VERSION})
+set(CPACK_SOURCE_IGNORE_FILES "/\\.git/" "/\\.cache/" "/build[^/]*/"
+    "/compile_commands\\.json$")
+include(CPack)
+
+add_custom_target(dist
+    COMMAND ${CMAKE_CPACK_COMMAND} --config CPackSourceConfig.cmake
+    COMMAND sha256sum ${CPACK_SOURCE_PACKAGE_FILE_NAME}.tar.gz > SHA256SUMS
+    WORKING_DIRECTORY ${CMAKE_BINARY_DIR}
+    COMMENT "Creating ${CPACK_SOURCE_PACKAGE_FILE_NAME}.tar.gz")
+`
 
-    set_target_properties(${PROJECT_NAME} PROPERTIES
-                          SUFFIX .so.${MAJOR_VERSION}.${MINOR_VERSION}.${RELEASE})
-else(SHARED)
-    add_library(${PROJECT_NAME} STATIC ${SOURCE})
-endif(SHARED)
+const libConfigContent = `@PACKAGE_INIT@
 
-install(TARGETS ${PROJECT_NAME} DESTINATION ${DESTINATION_BIN_DIR})
-install(FILES ${LIBRARY_HEADER} DESTINATION ${DESTINATION_HEADER_DIR}/${PROJECT_NAME})
-install(DIRECTORY ${CMAKE_CURRENT_SOURCE_DIR}/include/api DESTINATION ${DESTINATION_HEADER_DIR}/${PROJECT_NAME})
+include("${CMAKE_CURRENT_LIST_DIR}/{{.ProjectName}}Targets.cmake")
+
+check_required_components({{.ProjectName}})
 `
 
-const appContent = `project({{.ProjectName}})
-cmake_minimum_required(VERSION 2.8)
+const presetsContent = `{
+    "version": 3,
+    "cmakeMinimumRequired": {
+        "major": 3,
+        "minor": 21,
+        "patch": 0
+    },
+    "configurePresets": [
+        {
+            "name": "debug",
+            "displayName": "Debug",
+            "binaryDir": "${sourceDir}/build/debug",
+            "cacheVariables": {
+                "DEBUG": "ON"
+            }
+        },
+        {
+            "name": "release",
+            "displayName": "Release",
+            "binaryDir": "${sourceDir}/build/release",
+            "cacheVariables": {
+                "DEBUG": "OFF"
+            }
+        }
+    ],
+    "buildPresets": [
+        {
+            "name": "debug",
+            "configurePreset": "debug"
+        },
+        {
+            "name": "release",
+            "configurePreset": "release"
+        }
+    ]
+}
+`
+
+const appContent = `cmake_minimum_required(VERSION 3.21)
+
+# The application version is kept inside its definitions header
+set(DEFINITIONS_HEADER ${CMAKE_CURRENT_SOURCE_DIR}/include/{{.ProjectName}}_def.h)
+
+foreach(COMPONENT MAJOR_VERSION MINOR_VERSION RELEASE)
+    file(STRINGS ${DEFINITIONS_HEADER} DEFINE REGEX "define[ \t]+${COMPONENT}[ \t]")
+    string(REGEX REPLACE ".*${COMPONENT}[ \t]+([0-9]+).*" "\\1" ${COMPONENT} "${DEFINE}")
+endforeach()
+
+project({{.ProjectName}}
+    VERSION ${MAJOR_VERSION}.${MINOR_VERSION}.${RELEASE}
+    LANGUAGES C)
+
+# Default installation prefix, which may still be changed with
+# -DCMAKE_INSTALL_PREFIX
+if(CMAKE_INSTALL_PREFIX_INITIALIZED_TO_DEFAULT)
+    set(CMAKE_INSTALL_PREFIX "{{.Prefix}}" CACHE PATH "Installation prefix" FORCE)
+endif()
+
+include(GNUInstallDirs)
+
+set(CMAKE_EXPORT_COMPILE_COMMANDS ON)
+
+# Links the compilation database of the last configured build directory into
+# the project root, where .clangd looks for it
+file(CREATE_LINK ${CMAKE_BINARY_DIR}/compile_commands.json
+     ${CMAKE_SOURCE_DIR}/compile_commands.json SYMBOLIC)
 
 # Options
 option(DEBUG "Enable/Disable debug version" ON)
+option(COVERAGE "Enable/Disable code coverage instrumentation" OFF)
+set(SANITIZE "" CACHE STRING "Sanitizers to enable (e.g. address;undefined)")
 
-include_directories(include)
-include_directories("/usr/local/include")
+if(DEBUG)
+    set(CMAKE_BUILD_TYPE Debug)
+else()
+    set(CMAKE_BUILD_TYPE Release)
+endif()
 
-if(CMAKE_C_COMPILER_VERSION VERSION_GREATER 5)
-    add_definitions(-fgnu89-inline)
+if(SANITIZE)
+    string(REPLACE ";" "," SANITIZERS "${SANITIZE}")
+    add_compile_options(-fsanitize=${SANITIZERS} -fno-omit-frame-pointer)
+    add_link_options(-fsanitize=${SANITIZERS})
 endif()
 
-add_definitions("-Wall -Wextra -O0")
+if(COVERAGE)
+    add_compile_options(--coverage -O0)
+    add_link_options(--coverage)
+endif()
 
-if(DEBUG)
-    add_definitions("-ggdb")
-endif(DEBUG)
+file(GLOB SOURCES CONFIGURE_DEPENDS "src/*.c")
+file(GLOB TEST_SOURCES CONFIGURE_DEPENDS "tests/test_*.c")
 
-file(GLOB SOURCES "src/*c")
 add_executable(${PROJECT_NAME} ${SOURCES})
 
-link_directories("/usr/local/lib")
-target_link_libraries(${PROJECT_NAME} {{.LibcollectionsLinker}})
+# Unit tests, which may also run under valgrind with 'ctest -T memcheck'
+find_program(MEMORYCHECK_COMMAND valgrind)
+set(MEMORYCHECK_COMMAND_OPTIONS "--leak-check=full --error-exitcode=1")
+include(CTest)
+
+foreach(TEST_SOURCE ${TEST_SOURCES})
+    get_filename_component(TEST_NAME ${TEST_SOURCE} NAME_WE)
+    add_executable(${TEST_NAME} ${TEST_SOURCE})
+{{- if .CmockaLinker}}
+    target_link_libraries(${TEST_NAME} PRIVATE {{.CmockaLinker}})
+{{- end}}
+    add_test(NAME ${TEST_NAME} COMMAND ${TEST_NAME})
+    list(APPEND TEST_TARGETS ${TEST_NAME})
+endforeach()
+
+# The application and its tests are built the same way
+foreach(TARGET ${PROJECT_NAME} ${TEST_TARGETS})
+    target_include_directories(${TARGET} PRIVATE ${CMAKE_CURRENT_SOURCE_DIR}/include)
+    target_compile_options(${TARGET} PRIVATE -Wall -Wextra $<$<BOOL:${DEBUG}>:-ggdb>)
+
+    if(CMAKE_C_COMPILER_VERSION VERSION_GREATER 5)
+        target_compile_options(${TARGET} PRIVATE -fgnu89-inline)
+    endif()
+{{- if .LibcollectionsLinker}}
+
+    # libcollections may be installed under the same prefix
+    target_include_directories(${TARGET} PRIVATE ${CMAKE_INSTALL_FULL_INCLUDEDIR})
+    target_link_directories(${TARGET} PRIVATE ${CMAKE_INSTALL_FULL_LIBDIR})
+    target_link_libraries(${TARGET} PRIVATE {{.LibcollectionsLinker}})
+{{- end}}
+endforeach()
+
+install(TARGETS ${PROJECT_NAME} RUNTIME DESTINATION ${CMAKE_INSTALL_BINDIR})
+
+# HTML coverage report, built with 'make coverage'
+if(COVERAGE)
+    find_program(LCOV lcov)
+    find_program(GENHTML genhtml)
+
+    add_custom_target(coverage
+        COMMAND ${CMAKE_CTEST_COMMAND} --output-on-failure
+        COMMAND ${LCOV} --capture --directory . --output-file coverage.info
+        COMMAND ${LCOV} --remove coverage.info "/usr/*" "*/tests/*"
+            --output-file coverage.info
+        COMMAND ${GENHTML} coverage.info --output-directory coverage
+        WORKING_DIRECTORY ${CMAKE_BINARY_DIR}
+        COMMENT "Creating the coverage report at ${CMAKE_BINARY_DIR}/coverage")
+endif()
+
+# Source release tarball and its checksum, built with 'make dist'
+set(CPACK_SOURCE_GENERATOR TGZ)
+set(CPACK_SOURCE_PACKAGE_FILE_NAME ${PROJECT_NAME}-${PROJECT_VERSION})
+set(CPACK_SOURCE_IGNORE_FILES "/\\.git/" "/\\.cache/" "/build[^/]*/"
+    "/compile_commands\\.json$")
+include(CPack)
+
+add_custom_target(dist
+    COMMAND ${CMAKE_CPACK_COMMAND} --config CPackSourceConfig.cmake
+    COMMAND sha256sum ${CPACK_SOURCE_PACKAGE_FILE_NAME}.tar.gz > SHA256SUMS
+    WORKING_DIRECTORY ${CMAKE_BINARY_DIR}
+    COMMENT "Creating ${CPACK_SOURCE_PACKAGE_FILE_NAME}.tar.gz")
 `
 
-const pluginCMakeContent = `project({{.ProjectName}})
-cmake_minimum_required(VERSION 2.8)
+const pluginCMakeContent = `cmake_minimum_required(VERSION 3.21)
+
+project({{.ProjectName}} LANGUAGES C)
+
+# Default installation prefix, which may still be changed with
+# -DCMAKE_INSTALL_PREFIX
+if(CMAKE_INSTALL_PREFIX_INITIALIZED_TO_DEFAULT)
+    set(CMAKE_INSTALL_PREFIX "{{.Prefix}}" CACHE PATH "Installation prefix" FORCE)
+endif()
+
+include(GNUInstallDirs)
+
+set(CMAKE_EXPORT_COMPILE_COMMANDS ON)
+
+# Links the compilation database of the last configured build directory into
+# the project root, where .clangd looks for it
+file(CREATE_LINK ${CMAKE_BINARY_DIR}/compile_commands.json
+     ${CMAKE_SOURCE_DIR}/../compile_commands.json SYMBOLIC)
 
 # Options
 option(DEBUG "Enable/Disable debug version" ON)
 
-include_directories(include)
-include_directories("/usr/local/include")
-
-if(CMAKE_C_COMPILER_VERSION VERSION_GREATER 5)
-    add_definitions(-fgnu89-inline)
+if(DEBUG)
+    set(CMAKE_BUILD_TYPE Debug)
+else()
+    set(CMAKE_BUILD_TYPE Release)
 endif()
 
-add_definitions("-Wall -Wextra -O0 -fPIC -fvisibility=hidden -D_GNU_SOURCE")
+file(GLOB SOURCES CONFIGURE_DEPENDS "*.c")
+add_library(${PROJECT_NAME} SHARED ${SOURCES})
 
-if(DEBUG)
-    add_definitions("-ggdb -g3")
-endif(DEBUG)
+# libxante and libcollections may be installed under the same prefix
+target_include_directories(${PROJECT_NAME} PRIVATE
+    ${CMAKE_CURRENT_SOURCE_DIR}/../include
+    ${CMAKE_INSTALL_FULL_INCLUDEDIR})
 
-file(GLOB SOURCES "src/*c")
+target_compile_definitions(${PROJECT_NAME} PRIVATE _GNU_SOURCE)
+target_compile_options(${PROJECT_NAME} PRIVATE
+    -Wall -Wextra -fvisibility=hidden $<$<BOOL:${DEBUG}>:-ggdb> $<$<BOOL:${DEBUG}>:-g3>)
 
-link_directories("/usr/local/lib")
-add_library(${PROJECT_NAME} SHARED ${SOURCES})
-target_link_libraries(${PROJECT_NAME} xante collections)
+if(CMAKE_C_COMPILER_VERSION VERSION_GREATER 5)
+    target_compile_options(${PROJECT_NAME} PRIVATE -fgnu89-inline)
+endif()
+
+target_link_directories(${PROJECT_NAME} PRIVATE ${CMAKE_INSTALL_FULL_LIBDIR})
+target_link_libraries(${PROJECT_NAME} PRIVATE xante collections)
+
+# Plugins are loaded by their file name (and soname), without the lib prefix
 set_target_properties(${PROJECT_NAME} PROPERTIES
-                      LINK_FLAGS "-Wl,-soname,${PROJECT_NAME}.so")
+    PREFIX ""
+    SUFFIX .so)
 
-set_target_properties(${PROJECT_NAME} PROPERTIES SUFFIX .so)
-set_target_properties(${PROJECT_NAME} PROPERTIES PREFIX "")
+install(TARGETS ${PROJECT_NAME} LIBRARY DESTINATION ${CMAKE_INSTALL_LIBDIR})
 `
 
 const goPluginMakefile = `
-.PHONY: clean install purge
+.PHONY: clean install uninstall purge
 
 TARGET = {{.ProjectName}}.so
+PREFIX ?= {{.Prefix}}
+LIBDIR ?= $(PREFIX)/lib
 
 $(TARGET): plugin.go
 	go build -o $(TARGET) -buildmode=c-shared plugin.go
@@ -173,8 +452,323 @@ clean:
 
 purge: clean $(TARGET)
 
-install:
-	cp -f $(TARGET) /usr/local/lib
+install: $(TARGET)
+	install -d $(DESTDIR)$(LIBDIR)
+	install -m 0755 $(TARGET) $(DESTDIR)$(LIBDIR)
+
+uninstall:
+	rm -f $(DESTDIR)$(LIBDIR)/$(TARGET)
+`
+
+const makeLibContent = `# Options:
+#   DEBUG=0     builds the release version of the library
+#   SANITIZE    comma separated sanitizers to enable (e.g. address,undefined)
+#   COVERAGE=1  enables code coverage instrumentation ('make coverage')
+#   PREFIX      installation prefix (default: {{.Prefix}})
+#   DESTDIR     staging directory prepended to every installed file
+NAME := {{.ProjectName}}
+LIBRARY_HEADER := include/lib$(NAME).h
+VERSION_SCRIPT := misc/lib$(NAME).sym
+
+version_of = $(shell awk '/define/ && $$(NF-1) == "$(1)" { print $$NF }' $(LIBRARY_HEADER))
+MAJOR_VERSION := $(call version_of,MAJOR_VERSION)
+MINOR_VERSION := $(call version_of,MINOR_VERSION)
+RELEASE := $(call version_of,RELEASE)
+VERSION := $(MAJOR_VERSION).$(MINOR_VERSION).$(RELEASE)
+DIST := $(NAME)-$(VERSION)
+
+DEBUG ?= 1
+SANITIZE ?=
+COVERAGE ?= 0
+PREFIX ?= {{.Prefix}}
+LIBDIR ?= $(PREFIX)/lib
+INCLUDEDIR ?= $(PREFIX)/include
+BUILDDIR ?= build
+
+CPPFLAGS += -Iinclude -Iinclude/api -Iinclude/internal
+CPPFLAGS += -DLIB{{.ProjectNameUpper}}_COMPILE -D_GNU_SOURCE
+CFLAGS += -Wall -Wextra -fPIC
+LDLIBS +={{if .LibcollectionsLinker}} -l{{.LibcollectionsLinker}}{{end}}
+
+# The compiler already searches the /usr directories
+ifneq ($(PREFIX),/usr)
+LDFLAGS += -L$(LIBDIR)
+endif
+
+ifeq ($(DEBUG),1)
+CFLAGS += -ggdb -O0
+else
+CFLAGS += -O2
+endif
+
+ifneq ($(SANITIZE),)
+CFLAGS += -fsanitize=$(SANITIZE) -fno-omit-frame-pointer
+LDFLAGS += -fsanitize=$(SANITIZE)
+endif
+
+ifeq ($(COVERAGE),1)
+CFLAGS += --coverage
+LDFLAGS += --coverage
+endif
+
+ifeq ($(shell test $$($(CC) -dumpversion | cut -d. -f1) -gt 5 && echo y),y)
+CFLAGS += -fgnu89-inline
+endif
+
+SOURCES := $(wildcard src/*.c)
+OBJECTS := $(SOURCES:src/%.c=$(BUILDDIR)/%.o)
+
+SONAME := lib$(NAME).so.$(MAJOR_VERSION)
+SHARED_LIB := $(BUILDDIR)/lib$(NAME).so.$(VERSION)
+STATIC_LIB := $(BUILDDIR)/lib$(NAME).a
+PKGCONFIG := $(BUILDDIR)/lib$(NAME).pc
+
+TEST_SOURCES := $(wildcard tests/test_*.c)
+TESTS := $(TEST_SOURCES:tests/%.c=$(BUILDDIR)/tests/%)
+TEST_LDLIBS :={{if .CmockaLinker}} -l{{.CmockaLinker}}{{end}}
+
+.PHONY: all shared static check memcheck coverage install uninstall dist clean
+
+all: shared static $(PKGCONFIG)
+
+shared: $(SHARED_LIB)
+
+static: $(STATIC_LIB)
+
+$(SHARED_LIB): $(OBJECTS) $(VERSION_SCRIPT)
+	$(CC) -shared -Wl,-soname,$(SONAME) -Wl,--version-script=$(VERSION_SCRIPT) \
+		$(LDFLAGS) -o $@ $(OBJECTS) $(LDLIBS)
+	ln -sf $(notdir $@) $(BUILDDIR)/$(SONAME)
+	ln -sf $(SONAME) $(BUILDDIR)/lib$(NAME).so
+
+$(STATIC_LIB): $(OBJECTS)
+	$(AR) rcs $@ $^
+
+$(PKGCONFIG): misc/lib$(NAME).pc.in $(LIBRARY_HEADER) | $(BUILDDIR)
+	sed -e 's|@prefix@|$(PREFIX)|' -e 's|@libdir@|$(LIBDIR)|' \
+		-e 's|@includedir@|$(INCLUDEDIR)|' -e 's|@version@|$(VERSION)|' $< > $@
+
+$(BUILDDIR)/%.o: src/%.c | $(BUILDDIR)
+	$(CC) $(CPPFLAGS) $(CFLAGS) -MMD -MP -c -o $@ $<
+
+$(BUILDDIR)/tests/%: tests/%.c $(STATIC_LIB) | $(BUILDDIR)/tests
+	$(CC) $(CPPFLAGS) $(CFLAGS) -MMD -MP -o $@ $< $(STATIC_LIB) \
+		$(LDFLAGS) $(LDLIBS) $(TEST_LDLIBS)
+
+$(BUILDDIR) $(BUILDDIR)/tests:
+	mkdir -p $@
+
+check: $(TESTS)
+	@for test in $(abspath $(TESTS)); do $$test || exit 1; done
+
+memcheck: $(TESTS)
+	@for test in $(abspath $(TESTS)); do \
+		valgrind --leak-check=full --error-exitcode=1 $$test || exit 1; \
+	done
+
+coverage: check
+	lcov --capture --directory $(BUILDDIR) --output-file $(BUILDDIR)/coverage.info
+	lcov --remove $(BUILDDIR)/coverage.info "/usr/*" "*/tests/*" \
+		--output-file $(BUILDDIR)/coverage.info
+	genhtml $(BUILDDIR)/coverage.info --output-directory $(BUILDDIR)/coverage
+
+install: all
+	install -d $(DESTDIR)$(LIBDIR)/pkgconfig $(DESTDIR)$(INCLUDEDIR)/$(NAME)/api
+	install -m 0755 $(SHARED_LIB) $(DESTDIR)$(LIBDIR)
+	ln -sf lib$(NAME).so.$(VERSION) $(DESTDIR)$(LIBDIR)/$(SONAME)
+	ln -sf $(SONAME) $(DESTDIR)$(LIBDIR)/lib$(NAME).so
+	install -m 0644 $(STATIC_LIB) $(DESTDIR)$(LIBDIR)
+	install -m 0644 $(LIBRARY_HEADER) $(DESTDIR)$(INCLUDEDIR)/$(NAME)
+	install -m 0644 include/api/*.h $(DESTDIR)$(INCLUDEDIR)/$(NAME)/api
+	install -m 0644 $(PKGCONFIG) $(DESTDIR)$(LIBDIR)/pkgconfig
+
+uninstall:
+	rm -f $(DESTDIR)$(LIBDIR)/lib$(NAME).so* $(DESTDIR)$(LIBDIR)/lib$(NAME).a
+	rm -f $(DESTDIR)$(LIBDIR)/pkgconfig/lib$(NAME).pc
+	rm -rf $(DESTDIR)$(INCLUDEDIR)/$(NAME)
+
+dist: | $(BUILDDIR)
+	tar -czf $(BUILDDIR)/$(DIST).tar.gz --exclude-vcs --exclude='./build' \
+		--exclude='./build-*' --exclude='./builddir' --exclude='./.cache' \
+		--exclude='./compile_commands.json' --exclude='./$(BUILDDIR)' --transform 's,^\.,$(DIST),' .
+	cd $(BUILDDIR) && sha256sum $(DIST).tar.gz > SHA256SUMS
+
+clean:
+	rm -rf $(BUILDDIR)
+
+-include $(OBJECTS:.o=.d) $(TESTS:=.d)
+`
+
+const makeAppContent = `# Options:
+#   DEBUG=0     builds the release version of the application
+#   SANITIZE    comma separated sanitizers to enable (e.g. address,undefined)
+#   COVERAGE=1  enables code coverage instrumentation ('make coverage')
+#   PREFIX      installation prefix (default: {{.Prefix}})
+#   DESTDIR     staging directory prepended to every installed file
+NAME := {{.ProjectName}}
+DEFINITIONS_HEADER := include/$(NAME)_def.h
+
+version_of = $(shell awk '/define/ && $$(NF-1) == "$(1)" { print $$NF }' $(DEFINITIONS_HEADER))
+MAJOR_VERSION := $(call version_of,MAJOR_VERSION)
+MINOR_VERSION := $(call version_of,MINOR_VERSION)
+RELEASE := $(call version_of,RELEASE)
+VERSION := $(MAJOR_VERSION).$(MINOR_VERSION).$(RELEASE)
+DIST := $(NAME)-$(VERSION)
+
+DEBUG ?= 1
+SANITIZE ?=
+COVERAGE ?= 0
+PREFIX ?= {{.Prefix}}
+BINDIR ?= $(PREFIX)/bin
+LIBDIR ?= $(PREFIX)/lib
+INCLUDEDIR ?= $(PREFIX)/include
+BUILDDIR ?= build
+
+CPPFLAGS += -Iinclude
+CFLAGS += -Wall -Wextra
+LDLIBS +={{if .LibcollectionsLinker}} -l{{.LibcollectionsLinker}}{{end}}
+
+# The compiler already searches the /usr directories
+ifneq ($(PREFIX),/usr)
+CPPFLAGS += -I$(INCLUDEDIR)
+LDFLAGS += -L$(LIBDIR)
+endif
+
+ifeq ($(DEBUG),1)
+CFLAGS += -ggdb -O0
+else
+CFLAGS += -O2
+endif
+
+ifneq ($(SANITIZE),)
+CFLAGS += -fsanitize=$(SANITIZE) -fno-omit-frame-pointer
+LDFLAGS += -fsanitize=$(SANITIZE)
+endif
+
+ifeq ($(COVERAGE),1)
+CFLAGS += --coverage
+LDFLAGS += --coverage
+endif
+
+ifeq ($(shell test $$($(CC) -dumpversion | cut -d. -f1) -gt 5 && echo y),y)
+CFLAGS += -fgnu89-inline
+endif
+
+SOURCES := $(wildcard src/*.c)
+OBJECTS := $(SOURCES:src/%.c=$(BUILDDIR)/%.o)
+TARGET := $(BUILDDIR)/$(NAME)
+
+TEST_SOURCES := $(wildcard tests/test_*.c)
+TESTS := $(TEST_SOURCES:tests/%.c=$(BUILDDIR)/tests/%)
+TEST_LDLIBS :={{if .CmockaLinker}} -l{{.CmockaLinker}}{{end}}
+
+.PHONY: all check memcheck coverage install uninstall dist clean
+
+all: $(TARGET)
+
+$(TARGET): $(OBJECTS)
+	$(CC) $(LDFLAGS) -o $@ $^ $(LDLIBS)
+
+$(BUILDDIR)/%.o: src/%.c | $(BUILDDIR)
+	$(CC) $(CPPFLAGS) $(CFLAGS) -MMD -MP -c -o $@ $<
+
+$(BUILDDIR)/tests/%: tests/%.c | $(BUILDDIR)/tests
+	$(CC) $(CPPFLAGS) $(CFLAGS) -MMD -MP -o $@ $< $(LDFLAGS) $(LDLIBS) $(TEST_LDLIBS)
+
+$(BUILDDIR) $(BUILDDIR)/tests:
+	mkdir -p $@
+
+check: $(TESTS)
+	@for test in $(abspath $(TESTS)); do $$test || exit 1; done
+
+memcheck: $(TESTS)
+	@for test in $(abspath $(TESTS)); do \
+		valgrind --leak-check=full --error-exitcode=1 $$test || exit 1; \
+	done
+
+coverage: check
+	lcov --capture --directory $(BUILDDIR) --output-file $(BUILDDIR)/coverage.info
+	lcov --remove $(BUILDDIR)/coverage.info "/usr/*" "*/tests/*" \
+		--output-file $(BUILDDIR)/coverage.info
+	genhtml $(BUILDDIR)/coverage.info --output-directory $(BUILDDIR)/coverage
+
+install: all
+	install -d $(DESTDIR)$(BINDIR)
+	install -m 0755 $(TARGET) $(DESTDIR)$(BINDIR)
+
+uninstall:
+	rm -f $(DESTDIR)$(BINDIR)/$(NAME)
+
+dist: | $(BUILDDIR)
+	tar -czf $(BUILDDIR)/$(DIST).tar.gz --exclude-vcs --exclude='./build' \
+		--exclude='./build-*' --exclude='./builddir' --exclude='./.cache' \
+		--exclude='./compile_commands.json' --exclude='./$(BUILDDIR)' --transform 's,^\.,$(DIST),' .
+	cd $(BUILDDIR) && sha256sum $(DIST).tar.gz > SHA256SUMS
+
+clean:
+	rm -rf $(BUILDDIR)
+
+-include $(OBJECTS:.o=.d) $(TESTS:=.d)
+`
+
+const makePluginContent = `# Options:
+#   DEBUG=0     builds the release version of the plugin
+#   PREFIX      installation prefix (default: {{.Prefix}})
+#   DESTDIR     staging directory prepended to every installed file
+NAME := {{.ProjectName}}
+
+DEBUG ?= 1
+PREFIX ?= {{.Prefix}}
+LIBDIR ?= $(PREFIX)/lib
+INCLUDEDIR ?= $(PREFIX)/include
+BUILDDIR ?= build
+
+CPPFLAGS += -I../include -D_GNU_SOURCE
+CFLAGS += -Wall -Wextra -O0 -fPIC -fvisibility=hidden
+LDLIBS += -lxante -lcollections
+
+# The compiler already searches the /usr directories
+ifneq ($(PREFIX),/usr)
+CPPFLAGS += -I$(INCLUDEDIR)
+LDFLAGS += -L$(LIBDIR)
+endif
+
+ifeq ($(DEBUG),1)
+CFLAGS += -ggdb -g3
+endif
+
+ifeq ($(shell test $$($(CC) -dumpversion | cut -d. -f1) -gt 5 && echo y),y)
+CFLAGS += -fgnu89-inline
+endif
+
+SOURCES := $(wildcard *.c)
+OBJECTS := $(SOURCES:%.c=$(BUILDDIR)/%.o)
+TARGET := $(BUILDDIR)/$(NAME).so
+
+.PHONY: all install uninstall clean
+
+all: $(TARGET)
+
+$(TARGET): $(OBJECTS)
+	$(CC) -shared -Wl,-soname,$(NAME).so $(LDFLAGS) -o $@ $^ $(LDLIBS)
+
+$(BUILDDIR)/%.o: %.c | $(BUILDDIR)
+	$(CC) $(CPPFLAGS) $(CFLAGS) -MMD -MP -c -o $@ $<
+
+$(BUILDDIR):
+	mkdir -p $@
+
+install: all
+	install -d $(DESTDIR)$(LIBDIR)
+	install -m 0755 $(TARGET) $(DESTDIR)$(LIBDIR)
+
+uninstall:
+	rm -f $(DESTDIR)$(LIBDIR)/$(NAME).so
+
+clean:
+	rm -rf $(BUILDDIR)
+
+-include $(OBJECTS:.o=.d)
 `
 
 type Makefile struct {
@@ -198,16 +792,30 @@ func (m Makefile) Content(file *os.File) {
 	var content string
 	tpl := template.New("cmake")
 
-	if m.Options.ProjectType == base.LibraryProject {
-		content = libContent
+	if m.Options.Name == "CMakePresets.json" {
+		content = presetsContent
+	} else if strings.HasSuffix(m.Options.Name, "Config.cmake.in") {
+		content = libConfigContent
+	} else if m.Options.ProjectType == base.LibraryProject {
+		if m.Options.BuildSystem == base.MakeBuildSystem {
+			content = makeLibContent
+		} else {
+			content = libContent
+		}
 	} else if m.Options.ProjectType == base.XantePluginProject {
 		if m.Options.Language == base.GoLanguage {
 			content = goPluginMakefile
+		} else if m.Options.BuildSystem == base.MakeBuildSystem {
+			content = makePluginContent
 		} else {
 			content = pluginCMakeContent
 		}
 	} else {
-		content = appContent
+		if m.Options.BuildSystem == base.MakeBuildSystem {
+			content = makeAppContent
+		} else {
+			content = appContent
+		}
 	}
 
 	tpl, err := tpl.Parse(content)
@@ -226,6 +834,10 @@ func NewMakefile(options base.FileOptions) base.FileTemplate {
 		contentData.LibcollectionsLinker = "collections"
 	}
 
+	if options.CmockaTests {
+		contentData.CmockaLinker = "cmocka"
+	}
+
 	return &Makefile{
 		Options:     options,
 		ContentData: contentData,
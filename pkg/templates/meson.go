@@ -0,0 +1,350 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package templates
+
+import (
+	"os"
+	"text/template"
+
+	"source-template/pkg/base"
+)
+
+const mesonLibContent = `project('{{.ProjectName}}', 'c',
+        meson_version: '>= 0.57.0',
+        default_options: ['warning_level=2', 'prefix={{.Prefix}}'])
+
+fs = import('fs')
+cc = meson.get_compiler('c')
+
+# Links the compilation database into the project root, where .clangd looks
+# for it
+run_command('ln', '-sf', meson.current_build_dir() / 'compile_commands.json',
+            meson.current_source_dir(), check: false)
+
+# Library version, taken from its main header
+library_header = 'include/lib{{.ProjectName}}.h'
+
+foreach line : fs.read(library_header).split('\n')
+    fields = line.split()
+
+    if fields.length() >= 3 and fields[0].startswith('#')
+        if fields[-2] == 'MAJOR_VERSION'
+            major_version = fields[-1]
+        elif fields[-2] == 'MINOR_VERSION'
+            minor_version = fields[-1]
+        elif fields[-2] == 'RELEASE'
+            release = fields[-1]
+        endif
+    endif
+endforeach
+
+lib_version = '@0@.@1@.@2@'.format(major_version, minor_version, release)
+
+libdir = get_option('prefix') / get_option('libdir')
+includedir = get_option('prefix') / get_option('includedir')
+
+c_args = ['-DLIB{{.ProjectNameUpper}}_COMPILE', '-D_GNU_SOURCE']
+
+if cc.version().version_compare('>5')
+    c_args += '-fgnu89-inline'
+endif
+
+if get_option('debug_build')
+    c_args += ['-ggdb', '-O0']
+endif
+
+inc = include_directories('include', 'include/api', 'include/internal')
+sources = files('src/utils.c', 'src/error.c')
+version_script = meson.current_source_dir() / 'misc' / 'lib{{.ProjectName}}.sym'
+
+deps = []
+{{- if .LibcollectionsLinker}}
+deps += cc.find_library('{{.LibcollectionsLinker}}', dirs: [libdir])
+{{- end}}
+
+# Both library variants are built from the same objects
+libs = both_libraries('{{.ProjectName}}', sources,
+                      version: lib_version,
+                      soversion: major_version,
+                      include_directories: inc,
+                      c_args: c_args,
+                      dependencies: deps,
+                      link_args: '-Wl,--version-script,' + version_script,
+                      link_depends: version_script,
+                      pic: true,
+                      install: true)
+
+lib = libs.get_shared_lib()
+
+# Unit tests
+test_deps = deps
+{{- if .CmockaLinker}}
+test_deps += dependency('{{.CmockaLinker}}')
+{{- end}}
+
+foreach name : ['test_utils', 'test_error']
+    test(name, executable(name, 'tests' / name + '.c',
+                          include_directories: inc,
+                          c_args: c_args,
+                          link_with: lib,
+                          dependencies: test_deps))
+endforeach
+
+# Runs the unit tests under valgrind with 'meson test --setup memcheck'.
+# Sanitizers and coverage are built into meson, through the b_sanitize and
+# b_coverage options (e.g. -Db_sanitize=address,undefined).
+add_test_setup('memcheck',
+               exe_wrapper: ['valgrind', '--leak-check=full', '--error-exitcode=1'],
+               timeout_multiplier: 10)
+
+install_headers(library_header, subdir: '{{.ProjectName}}')
+install_subdir('include/api', install_dir: get_option('includedir') / '{{.ProjectName}}')
+
+# pkg-config support
+pc_data = configuration_data({
+    'prefix': get_option('prefix'),
+    'libdir': libdir,
+    'includedir': includedir,
+    'version': lib_version,
+})
+
+configure_file(input: 'misc/lib{{.ProjectName}}.pc.in',
+               output: 'lib{{.ProjectName}}.pc',
+               configuration: pc_data,
+               install_dir: get_option('libdir') / 'pkgconfig')
+
+# Source release tarball and its checksum, built with 'ninja tarball', since
+# meson reserves the dist target for 'meson dist', which needs a git checkout.
+dist_name = '{{.ProjectName}}-' + lib_version
+
+run_target('tarball',
+           command: ['sh', '-c',
+                     'tar -czf "$MESON_BUILD_ROOT/$1.tar.gz" --exclude-vcs ' +
+                     '--exclude=./build --exclude="./build-*" --exclude=./builddir ' +
+                     '--exclude=./.cache --exclude=./compile_commands.json ' +
+                     '--transform "s,^\\.,$1," -C "$MESON_SOURCE_ROOT" . && ' +
+                     'cd "$MESON_BUILD_ROOT" && sha256sum "$1.tar.gz" > SHA256SUMS',
+                     'sh', dist_name])
+`
+
+const mesonLibOptionsContent = `option('debug_build', type: 'boolean', value: true,
+       description: 'Enable/Disable debug library')
+`
+
+const mesonAppContent = `project('{{.ProjectName}}', 'c',
+        meson_version: '>= 0.57.0',
+        default_options: ['warning_level=2', 'prefix={{.Prefix}}'])
+
+fs = import('fs')
+cc = meson.get_compiler('c')
+
+# Links the compilation database into the project root, where .clangd looks
+# for it
+run_command('ln', '-sf', meson.current_build_dir() / 'compile_commands.json',
+            meson.current_source_dir(), check: false)
+
+# Application version, taken from its definitions header
+foreach line : fs.read('include/{{.ProjectName}}_def.h').split('\n')
+    fields = line.split()
+
+    if fields.length() >= 3 and fields[0].startswith('#')
+        if fields[-2] == 'MAJOR_VERSION'
+            major_version = fields[-1]
+        elif fields[-2] == 'MINOR_VERSION'
+            minor_version = fields[-1]
+        elif fields[-2] == 'RELEASE'
+            release = fields[-1]
+        endif
+    endif
+endforeach
+
+app_version = '@0@.@1@.@2@'.format(major_version, minor_version, release)
+libdir = get_option('prefix') / get_option('libdir')
+includedir = get_option('prefix') / get_option('includedir')
+
+c_args = ['-O0', '-I' + includedir]
+
+if cc.version().version_compare('>5')
+    c_args += '-fgnu89-inline'
+endif
+
+if get_option('debug_build')
+    c_args += '-ggdb'
+endif
+
+deps = []
+{{- if .LibcollectionsLinker}}
+deps += cc.find_library('{{.LibcollectionsLinker}}', dirs: [libdir])
+{{- end}}
+
+inc = include_directories('include')
+
+executable('{{.ProjectName}}', files('src/main.c'),
+           include_directories: inc,
+           c_args: c_args,
+           dependencies: deps,
+           install: true)
+
+# Unit tests
+test_deps = deps
+{{- if .CmockaLinker}}
+test_deps += dependency('{{.CmockaLinker}}')
+{{- end}}
+
+test_name = 'test_{{.ProjectName}}'
+test(test_name, executable(test_name, 'tests' / test_name + '.c',
+                           include_directories: inc,
+                           c_args: c_args,
+                           dependencies: test_deps))
+
+# Runs the unit tests under valgrind with 'meson test --setup memcheck'.
+# Sanitizers and coverage are built into meson, through the b_sanitize and
+# b_coverage options (e.g. -Db_sanitize=address,undefined).
+add_test_setup('memcheck',
+               exe_wrapper: ['valgrind', '--leak-check=full', '--error-exitcode=1'],
+               timeout_multiplier: 10)
+
+# Source release tarball and its checksum, built with 'ninja tarball', since
+# meson reserves the dist target for 'meson dist', which needs a git checkout.
+dist_name = '{{.ProjectName}}-' + app_version
+
+run_target('tarball',
+           command: ['sh', '-c',
+                     'tar -czf "$MESON_BUILD_ROOT/$1.tar.gz" --exclude-vcs ' +
+                     '--exclude=./build --exclude="./build-*" --exclude=./builddir ' +
+                     '--exclude=./.cache --exclude=./compile_commands.json ' +
+                     '--transform "s,^\\.,$1," -C "$MESON_SOURCE_ROOT" . && ' +
+                     'cd "$MESON_BUILD_ROOT" && sha256sum "$1.tar.gz" > SHA256SUMS',
+                     'sh', dist_name])
+`
+
+const mesonPluginContent = `project('{{.ProjectName}}', 'c',
+        meson_version: '>= 0.57.0',
+        default_options: ['warning_level=2', 'prefix={{.Prefix}}'])
+
+cc = meson.get_compiler('c')
+
+# Links the compilation database into the project root, where .clangd looks
+# for it
+run_command('ln', '-sf', meson.current_build_dir() / 'compile_commands.json',
+            meson.current_source_dir() / '..', check: false)
+libdir = get_option('prefix') / get_option('libdir')
+includedir = get_option('prefix') / get_option('includedir')
+
+c_args = ['-O0', '-fvisibility=hidden', '-D_GNU_SOURCE', '-I' + includedir]
+
+if cc.version().version_compare('>5')
+    c_args += '-fgnu89-inline'
+endif
+
+if get_option('debug_build')
+    c_args += ['-ggdb', '-g3']
+endif
+
+deps = [
+    cc.find_library('xante', dirs: [libdir]),
+    cc.find_library('collections', dirs: [libdir]),
+]
+
+shared_module('{{.ProjectName}}', files('plugin.c'),
+              include_directories: include_directories('../include'),
+              c_args: c_args,
+              dependencies: deps,
+              link_args: '-Wl,-soname,{{.ProjectName}}.so',
+              name_prefix: '',
+              name_suffix: 'so',
+              install: true,
+              install_dir: get_option('libdir'))
+`
+
+const mesonOptionsContent = `option('debug_build', type: 'boolean', value: true,
+       description: 'Enable/Disable debug version')
+`
+
+type MesonFile struct {
+	Options base.FileOptions
+	ContentData
+}
+
+func (m MesonFile) Header(file *os.File) {
+	// nothing here
+}
+
+func (m MesonFile) HeaderComment(file *os.File) {
+	// nothing here
+}
+
+func (m MesonFile) Footer(file *os.File) {
+	// nothing here
+}
+
+func (m MesonFile) Content(file *os.File) {
+	var content string
+	tpl := template.New("meson")
+	options := m.Options.Name == "meson_options.txt"
+
+	switch m.Options.ProjectType {
+	case base.LibraryProject:
+		if options {
+			content = mesonLibOptionsContent
+		} else {
+			content = mesonLibContent
+		}
+
+	case base.XantePluginProject:
+		if options {
+			content = mesonOptionsContent
+		} else {
+			content = mesonPluginContent
+		}
+
+	default:
+		if options {
+			content = mesonOptionsContent
+		} else {
+			content = mesonAppContent
+		}
+	}
+
+	tpl, err := tpl.Parse(content)
+
+	if err != nil {
+		return
+	}
+
+	tpl.Execute(file, m.ContentData)
+}
+
+// NewMeson creates a meson build file template, which can be the project's
+// meson.build or its meson_options.txt, depending on the file name.
+func NewMeson(options base.FileOptions) base.FileTemplate {
+	contentData := GetContentData(options)
+
+	if options.LibcollectionsFeatures {
+		contentData.LibcollectionsLinker = "collections"
+	}
+
+	if options.CmockaTests {
+		contentData.CmockaLinker = "cmocka"
+	}
+
+	return &MesonFile{
+		Options:     options,
+		ContentData: contentData,
+	}
+}
@@ -0,0 +1,347 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package templates
+
+import (
+	"os"
+	"strings"
+	"text/template"
+
+	"source-template/pkg/base"
+)
+
+const pkgbuildContent = `# Maintainer: {{.Author}} <{{.Email}}>
+
+pkgname={{.ProjectName}}
+pkgver=0.1.1
+pkgrel=1
+pkgdesc="{{.Summary}}"
+arch=({{if eq .ProjectTypeName "script"}}'any'{{else}}'x86_64' 'aarch64'{{end}})
+license=('LicenseRef-proprietary')
+depends=({{range $i, $d := .Depends}}{{if $i}} {{end}}'{{$d}}'{{end}})
+makedepends=({{range $i, $d := .MakeDepends}}{{if $i}} {{end}}'{{$d}}'{{end}})
+{{- if .CheckDepends}}
+checkdepends=({{range $i, $d := .CheckDepends}}{{if $i}} {{end}}'{{$d}}'{{end}})
+{{- end}}
+{{- if or .ConfigFile .LogFile}}
+backup=({{if .ConfigFile}}'{{slice .ConfigFile 1}}'{{end}}{{if and .ConfigFile .LogFile}} {{end}}{{if .LogFile}}'etc/logrotate.d/{{.ProjectName}}'{{end}})
+{{- end}}
+source=()
+
+# The package is built from the project tree, next to pkg_install
+_srcdir="$startdir/../../{{.ProjectName}}{{.SourceDir}}"
+
+build() {
+	cd "$_srcdir"
+{{- template "build" .}}
+}
+{{- if .Tests}}
+
+check() {
+	cd "$_srcdir"
+{{- template "check" .}}
+}
+{{- end}}
+
+package() {
+	cd "$_srcdir"
+{{- template "install" .}}
+{{- range .Units}}
+	install -Dm644 debian/{{.}} "$pkgdir/usr/lib/systemd/system/{{.}}"
+{{- end}}
+{{- template "misc" .}}
+{{- if .User}}
+	echo 'u {{.User}} - "{{.ProjectName}} service user" /var/lib/{{.ProjectName}}' | \
+		install -Dm644 /dev/stdin "$pkgdir/usr/lib/sysusers.d/$pkgname.conf"
+{{- end}}
+}
+`
+
+const apkbuildContent = `# Maintainer: {{.Author}} <{{.Email}}>
+pkgname={{.ProjectName}}
+pkgver=0.1.1
+pkgrel=0
+pkgdesc="{{.Summary}}"
+url="https://localhost/{{.ProjectName}}"
+arch="{{if eq .ProjectTypeName "script"}}noarch{{else}}all{{end}}"
+license="custom"
+depends="{{range $i, $d := .Depends}}{{if $i}} {{end}}{{$d}}{{end}}"
+makedepends="{{range $i, $d := .MakeDepends}}{{if $i}} {{end}}{{$d}}{{end}}"
+{{- if .CheckDepends}}
+checkdepends="{{range $i, $d := .CheckDepends}}{{if $i}} {{end}}{{$d}}{{end}}"
+{{- end}}
+{{- if .Subpackages}}
+subpackages="{{range $i, $d := .Subpackages}}{{if $i}} {{end}}{{$d}}{{end}}"
+{{- end}}
+{{- if .User}}
+pkgusers="{{.User}}"
+pkggroups="{{.User}}"
+install="$pkgname.pre-install"
+{{- end}}
+{{- if not .Tests}}
+options="!check"
+{{- end}}
+source=""
+
+# The package is built from the project tree, next to pkg_install
+builddir="$startdir/../../{{.ProjectName}}{{.SourceDir}}"
+
+build() {
+{{- template "build" .}}
+}
+{{- if .Tests}}
+
+check() {
+{{- template "check" .}}
+}
+{{- end}}
+
+package() {
+{{- template "install" .}}
+{{- template "misc" .}}
+{{- if .InitScript}}
+	install -Dm755 "$startdir/$pkgname.initd" "$pkgdir/etc/init.d/$pkgname"
+{{- end}}
+}
+`
+
+// Build, test and install steps shared by the PKGBUILD and APKBUILD files,
+// the same ones build-package.sh uses to compile a project, along with the
+// installation of the configuration and logrotate files.
+const packageBuildStepsContent = `
+{{- define "build"}}
+{{- if eq .BuildTool "cmake"}}
+	cmake -B "$srcdir/build" -DCMAKE_INSTALL_PREFIX=/usr -DCMAKE_BUILD_TYPE=None \
+		-DDEBUG=OFF
+	cmake --build "$srcdir/build"
+{{- else if eq .BuildTool "meson"}}
+	meson setup --prefix /usr --buildtype plain -Ddebug_build=false "$srcdir/build"
+	meson compile -C "$srcdir/build"
+{{- else if eq .BuildTool "make"}}
+	make {{.MakeVariables}}
+{{- else}}
+	bash -n src/$pkgname
+{{- end}}
+{{- end}}
+
+{{- define "check"}}
+{{- if eq .BuildTool "cmake"}}
+	ctest --test-dir "$srcdir/build" --output-on-failure
+{{- else if eq .BuildTool "meson"}}
+	meson test -C "$srcdir/build"
+{{- else if eq .BuildTool "make"}}
+	make {{.MakeVariables}} check
+{{- else}}
+	if command -v bats > /dev/null; then bats tests; fi
+{{- end}}
+{{- end}}
+
+{{- define "misc"}}
+{{- if .ConfigFile}}
+	install -Dm644 debian/$pkgname.conf "$pkgdir{{.ConfigFile}}"
+{{- end}}
+{{- if .LogFile}}
+	install -Dm644 debian/$pkgname.logrotate "$pkgdir/etc/logrotate.d/$pkgname"
+{{- end}}
+{{- end}}
+
+{{- define "install"}}
+{{- if eq .BuildTool "cmake"}}
+	DESTDIR="$pkgdir" cmake --install "$srcdir/build"
+{{- else if eq .BuildTool "meson"}}
+	meson install -C "$srcdir/build" --destdir "$pkgdir"
+{{- else if eq .BuildTool "make"}}
+	make {{.MakeVariables}} DESTDIR="$pkgdir" install
+{{- else}}
+	install -Dm755 src/$pkgname "$pkgdir/usr/bin/$pkgname"
+{{- end}}
+{{- end}}
+`
+
+const alpinePreInstallContent = `#!/bin/sh
+
+addgroup -S {{.User}} 2> /dev/null
+adduser -S -D -H -h /var/lib/{{.ProjectName}} -s /sbin/nologin -G {{.User}} \
+	-g "{{.ProjectName}} service user" {{.User}} 2> /dev/null
+
+exit 0
+`
+
+// PkgbuildData holds what is needed to describe a project as an Arch Linux
+// or Alpine Linux package.
+type PkgbuildData struct {
+	ContentData
+	Email         string
+	Summary       string
+	SourceDir     string
+	BuildTool     string
+	MakeVariables string
+	Depends       []string
+	MakeDepends   []string
+	CheckDepends  []string
+	Subpackages   []string
+	Units         []string
+	User          string
+	Tests         bool
+	InitScript    bool
+}
+
+type PkgbuildFile struct {
+	content string
+	base.FileOptions
+	PkgbuildData
+}
+
+func (p PkgbuildFile) Header(file *os.File) {
+}
+
+func (p PkgbuildFile) HeaderComment(file *os.File) {
+}
+
+func (p PkgbuildFile) Footer(file *os.File) {
+}
+
+func (p PkgbuildFile) Content(file *os.File) {
+	tpl, err := template.New("pkgbuild").Parse(p.content)
+
+	if err != nil {
+		return
+	}
+
+	if _, err := tpl.Parse(packageBuildStepsContent); err != nil {
+		return
+	}
+
+	tpl.Execute(file, p.PkgbuildData)
+}
+
+// HasInitScript tells if a project is packaged with an OpenRC init script,
+// the Alpine Linux counterpart of its systemd service. Timer activated
+// services have none, since OpenRC only supervises long-running daemons.
+func HasInitScript(options base.ProjectOptions) bool {
+	return options.HasService() && options.ServiceTimer == ""
+}
+
+func getPkgbuildData(options base.FileOptions, alpine bool) PkgbuildData {
+	data := PkgbuildData{
+		ContentData:   GetContentData(options),
+		Email:         options.Email,
+		BuildTool:     rpmBuildTool(options),
+		MakeVariables: `BUILDDIR="$srcdir/build" DEBUG=0 PREFIX=/usr LIBDIR=/usr/lib`,
+		User:          options.ServiceUser,
+		Tests: options.ProjectType == base.ApplicationProject ||
+			options.ProjectType == base.LibraryProject ||
+			options.ProjectType == base.ScriptProject,
+	}
+
+	// Arch packages are installed along with their systemd units, while the
+	// Alpine ones use OpenRC
+	if alpine {
+		data.InitScript = HasInitScript(options.ProjectOptions)
+	} else {
+		data.Units = SystemdUnits(options.ProjectOptions)
+	}
+
+	switch data.BuildTool {
+	case "cmake", "meson":
+		data.MakeDepends = []string{data.BuildTool}
+
+	case "make":
+		data.MakeDepends = []string{"make"}
+	}
+
+	// Alpine splits headers and static libraries into -dev packages
+	dev := ""
+
+	if alpine {
+		dev = "-dev"
+	}
+
+	if options.LibcollectionsFeatures {
+		data.Depends = append(data.Depends, "libcollections")
+		data.MakeDepends = append(data.MakeDepends, "libcollections"+dev)
+	}
+
+	if options.CmockaTests {
+		data.CheckDepends = append(data.CheckDepends, "cmocka"+dev)
+	}
+
+	switch options.ProjectType {
+	case base.ApplicationProject:
+		data.Summary = "The " + options.ProjectName + " application"
+
+	case base.LibraryProject:
+		data.Summary = "The " + options.ProjectName + " library"
+
+		if alpine {
+			data.Subpackages = append(data.Subpackages, "$pkgname-dev")
+		}
+
+	case base.XantePluginProject:
+		data.Summary = "The " + options.ProjectName + " xante plugin"
+		data.SourceDir = "/src"
+
+		if options.Language == base.GoLanguage {
+			data.MakeDepends = []string{"go", "make"}
+			data.MakeVariables = "PREFIX=/usr LIBDIR=/usr/lib"
+		} else {
+			data.Depends = append(data.Depends, "libxante", "libcollections")
+			data.MakeDepends = append(data.MakeDepends,
+				"libxante"+dev, "libcollections"+dev)
+		}
+
+	case base.ScriptProject:
+		data.Summary = "The " + options.ProjectName + " script"
+		data.Depends = []string{"bash"}
+	}
+
+	// Alpine finds shared library dependencies by itself
+	if alpine && options.ProjectType != base.ScriptProject {
+		data.Depends = nil
+	}
+
+	if data.InitScript {
+		data.Subpackages = append(data.Subpackages, "$pkgname-openrc")
+	}
+
+	return data
+}
+
+// NewPkgbuild creates the template of one of the files describing a project
+// as an Arch Linux (PKGBUILD) or Alpine Linux (APKBUILD) package.
+func NewPkgbuild(options base.FileOptions) base.FileTemplate {
+	var content string
+	alpine := options.Name != "PKGBUILD"
+
+	switch {
+	case options.Name == "PKGBUILD":
+		content = pkgbuildContent
+
+	case options.Name == "APKBUILD":
+		content = apkbuildContent
+
+	case strings.HasSuffix(options.Name, ".pre-install"):
+		content = alpinePreInstallContent
+	}
+
+	return &PkgbuildFile{
+		FileOptions:  options,
+		content:      content,
+		PkgbuildData: getPkgbuildData(options, alpine),
+	}
+}
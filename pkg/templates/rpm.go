@@ -0,0 +1,322 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package templates
+
+import (
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"source-template/pkg/base"
+)
+
+const specContent = `Name:           {{.ProjectName}}
+Version:        0.1.1
+Release:        1%{?dist}
+Summary:        {{.Summary}}
+License:        Proprietary
+Source0:        %{name}-%{version}.tar.gz
+{{- if eq .ProjectTypeName "script"}}
+BuildArch:      noarch
+Requires:       bash
+{{- else}}
+{{- range .BuildRequires}}
+BuildRequires:  {{.}}
+{{- end}}
+{{- end}}
+{{- if .Units}}
+BuildRequires:  systemd-rpm-macros
+{{- end}}
+{{- if .ServiceUserName}}
+Requires(pre):  shadow-utils
+{{- end}}
+{{- if eq .ProjectTypeName "xante-plugin"}}
+
+%global _vpath_srcdir src
+{{- end}}
+{{- if eq .LanguageName "go"}}
+%global debug_package %{nil}
+{{- end}}
+
+%description
+Replace this text with a longer description of {{.ProjectName}}.
+{{- if eq .ProjectTypeName "library"}}
+
+%package devel
+Summary:        Development files for the {{.ProjectName}} library
+Requires:       %{name}%{?_isa} = %{version}-%{release}
+
+%description devel
+Headers, pkg-config file and static library needed to build applications
+using the {{.ProjectName}} library.
+{{- end}}
+
+%prep
+%autosetup
+
+%build
+{{- if eq .BuildTool "cmake"}}
+%cmake -DDEBUG=OFF
+%cmake_build
+{{- else if eq .BuildTool "meson"}}
+%meson -Ddebug_build=false
+%meson_build
+{{- else if eq .BuildTool "make"}}
+%make_build {{.MakeVariables}}
+{{- else}}
+bash -n src/%{name}
+{{- end}}
+
+%install
+{{- if eq .BuildTool "cmake"}}
+%cmake_install
+{{- else if eq .BuildTool "meson"}}
+%meson_install
+{{- else if eq .BuildTool "make"}}
+%make_install {{.MakeVariables}}
+{{- else}}
+install -D -m 0755 src/%{name} %{buildroot}%{_bindir}/%{name}
+{{- end}}
+{{- range .Units}}
+install -D -m 0644 debian/{{.}} %{buildroot}%{_unitdir}/{{.}}
+{{- end}}
+{{- if .ConfigFile}}
+install -D -m 0644 debian/%{name}.conf %{buildroot}{{.ConfigFile}}
+{{- end}}
+{{- if .LogFile}}
+install -D -m 0644 debian/%{name}.logrotate %{buildroot}%{_sysconfdir}/logrotate.d/%{name}
+{{- end}}
+{{- if .ServiceUserName}}
+echo 'u {{.ServiceUserName}} - "{{.ProjectName}} service user" /var/lib/{{.ProjectName}}' | \
+    install -D -m 0644 /dev/stdin %{buildroot}%{_sysusersdir}/%{name}.conf
+{{- end}}
+{{- if .Tests}}
+
+%check
+{{- if eq .BuildTool "cmake"}}
+%ctest
+{{- else if eq .BuildTool "meson"}}
+%meson_test
+{{- else if eq .BuildTool "make"}}
+%make_build check
+{{- else}}
+if command -v bats > /dev/null; then bats tests; fi
+{{- end}}
+{{- end}}
+{{- if .ServiceUserName}}
+
+%pre
+getent group {{.ServiceUserName}} > /dev/null || groupadd -r {{.ServiceUserName}}
+getent passwd {{.ServiceUserName}} > /dev/null || \
+    useradd -r -g {{.ServiceUserName}} -d /var/lib/{{.ProjectName}} \
+    -s /sbin/nologin -c "{{.ProjectName}} service user" {{.ServiceUserName}}
+exit 0
+{{- end}}
+{{- if .Units}}
+
+%post
+%systemd_post {{.UnitNames}}
+
+%preun
+%systemd_preun {{.UnitNames}}
+
+%postun
+{{- if .Timer}}
+%systemd_postun {{.UnitNames}}
+{{- else}}
+%systemd_postun_with_restart {{.UnitNames}}
+{{- end}}
+{{- end}}
+{{- if eq .ProjectTypeName "library"}}
+
+%ldconfig_scriptlets
+{{- end}}
+
+%files
+{{- range .Files}}
+{{.}}
+{{- end}}
+{{- range .Units}}
+%{_unitdir}/{{.}}
+{{- end}}
+{{- if .ServiceUserName}}
+%{_sysusersdir}/%{name}.conf
+{{- end}}
+{{- if .ConfigFile}}
+%dir %{_sysconfdir}/%{name}
+%config(noreplace) {{.ConfigFile}}
+{{- end}}
+{{- if .LogFile}}
+%config(noreplace) %{_sysconfdir}/logrotate.d/%{name}
+{{- end}}
+{{- if eq .ProjectTypeName "library"}}
+
+%files devel
+{{- range .DevelFiles}}
+{{.}}
+{{- end}}
+{{- end}}
+
+%changelog
+* {{.ChangelogDate}} {{.Author}} <{{.Email}}> - 0.1.1-1
+- Initial release.
+`
+
+// SpecData holds what is needed to describe a project as a RPM package.
+type SpecData struct {
+	ContentData
+	Email         string
+	Summary       string
+	ChangelogDate string
+	BuildTool     string
+	MakeVariables string
+	BuildRequires []string
+	Files         []string
+	DevelFiles    []string
+	Units         []string
+	UnitNames     string
+	Timer         bool
+	Tests         bool
+}
+
+type SpecFile struct {
+	base.FileOptions
+	SpecData
+}
+
+func (s SpecFile) Header(file *os.File) {
+}
+
+func (s SpecFile) HeaderComment(file *os.File) {
+}
+
+func (s SpecFile) Footer(file *os.File) {
+}
+
+func (s SpecFile) Content(file *os.File) {
+	tpl, err := template.New("spec").Parse(specContent)
+
+	if err != nil {
+		return
+	}
+
+	tpl.Execute(file, s.SpecData)
+}
+
+// rpmBuildTool gives the tool whose RPM macros build a project, or an empty
+// string when it has nothing to build.
+func rpmBuildTool(options base.FileOptions) string {
+	if options.ProjectType == base.ScriptProject {
+		return ""
+	}
+
+	if options.Language == base.GoLanguage {
+		return "make"
+	}
+
+	buildSystem, _ := base.BuildSystemKey(options.BuildSystem)
+
+	return buildSystem
+}
+
+func getSpecData(options base.FileOptions) SpecData {
+	data := SpecData{
+		ContentData:   GetContentData(options),
+		Email:         options.Email,
+		ChangelogDate: time.Now().Format("Mon Jan 02 2006"),
+		BuildTool:     rpmBuildTool(options),
+		MakeVariables: "DEBUG=0 PREFIX=%{_prefix} LIBDIR=%{_libdir}",
+		Units:         SystemdUnits(options.ProjectOptions),
+		Timer:         options.ServiceTimer != "",
+		Tests: options.ProjectType == base.ApplicationProject ||
+			options.ProjectType == base.LibraryProject ||
+			options.ProjectType == base.ScriptProject,
+	}
+
+	data.UnitNames = strings.Join(data.Units, " ")
+
+	switch data.BuildTool {
+	case "cmake":
+		data.BuildRequires = []string{"gcc", "cmake"}
+
+	case "meson":
+		data.BuildRequires = []string{"gcc", "meson", "ninja-build"}
+
+	case "make":
+		data.BuildRequires = []string{"gcc", "make"}
+	}
+
+	if options.LibcollectionsFeatures {
+		data.BuildRequires = append(data.BuildRequires, "libcollections-devel")
+	}
+
+	if options.CmockaTests {
+		data.BuildRequires = append(data.BuildRequires, "libcmocka-devel")
+	}
+
+	switch options.ProjectType {
+	case base.ApplicationProject:
+		data.Summary = "The " + options.ProjectName + " application"
+		data.Files = []string{"%{_bindir}/%{name}"}
+
+	case base.LibraryProject:
+		data.Summary = "The " + options.ProjectName + " library"
+		data.Files = []string{"%{_libdir}/lib%{name}.so.*"}
+		data.DevelFiles = []string{
+			"%{_includedir}/%{name}",
+			"%{_libdir}/lib%{name}.so",
+			"%{_libdir}/lib%{name}.a",
+			"%{_libdir}/pkgconfig/lib%{name}.pc",
+		}
+
+		if options.BuildSystem == base.CMakeBuildSystem {
+			data.DevelFiles = append(data.DevelFiles, "%{_libdir}/cmake/%{name}")
+		}
+
+	case base.XantePluginProject:
+		data.Summary = "The " + options.ProjectName + " xante plugin"
+		data.Files = []string{"%{_libdir}/%{name}.so"}
+		data.MakeVariables = "-C src " + data.MakeVariables
+
+		if options.Language == base.GoLanguage {
+			data.BuildRequires = []string{"golang", "make"}
+		} else {
+			data.BuildRequires = append(data.BuildRequires, "libxante-devel")
+
+			// Already required by libcollections features
+			if !options.LibcollectionsFeatures {
+				data.BuildRequires = append(data.BuildRequires, "libcollections-devel")
+			}
+		}
+
+	case base.ScriptProject:
+		data.Summary = "The " + options.ProjectName + " script"
+		data.Files = []string{"%{_bindir}/%{name}"}
+	}
+
+	return data
+}
+
+// NewSpec creates the RPM spec file template of a package project.
+func NewSpec(options base.FileOptions) base.FileTemplate {
+	return &SpecFile{
+		FileOptions: options,
+		SpecData:    getSpecData(options),
+	}
+}
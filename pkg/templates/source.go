@@ -21,6 +21,7 @@ package templates
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/template"
 
 	"source-template/pkg/base"
@@ -81,6 +82,18 @@ func (s SourceFile) Content(file *os.File) {
 	tpl.Execute(file, s.ContentData)
 }
 
+// utilsContent gives the library version, as it was built.
+const utilsContent = `
+#define VERSION_STRING_(x)              #x
+#define VERSION_STRING(x)               VERSION_STRING_(x)
+
+__PUB_API__ const char *{{.ProjectName}}_version(void)
+{
+    return VERSION_STRING(MAJOR_VERSION) "." VERSION_STRING(MINOR_VERSION) "."
+           VERSION_STRING(RELEASE);
+}
+`
+
 const mainContent = `
 static void usage(void)
 {
@@ -89,6 +102,12 @@ static void usage(void)
     printf("Options:\n\n");
     printf("  -h\tShows this help screen.\n");
     printf("  -v\tShows current {{.ProjectName}} version.\n");
+{{- if .ConfigFile}}
+    printf("  -c, --config <file>\tLoads the settings from a configuration file.\n");
+{{- end}}
+{{- if .LogFile}}
+    printf("  -l, --log-file <file>\tWrites the log messages into a file.\n");
+{{- end}}
     printf("\n");
 }
 
@@ -98,13 +117,180 @@ static void version(void)
            RELEASE, (BETA == true) ? "beta" : "");
 }
 
+{{- if .ConfigFile}}
+
+/*
+ * Loads the 'key = value' settings of a configuration file, ignoring empty
+ * lines and comments.
+ */
+static int config_load(const char *filename, struct {{.ProjectName}}_config *config)
+{
+    FILE *fp;
+    char line[512], key[128], value[256];
+
+    fp = fopen(filename, "r");
+
+    if (NULL == fp) {
+        fprintf(stderr, "%s: could not open '%s'\n", APP_NAME, filename);
+        return -1;
+    }
+
+    while (fgets(line, sizeof(line), fp) != NULL) {
+        if ((line[0] == '#') || (line[0] == '\n'))
+            continue;
+
+        if (sscanf(line, " %127[^= \t] = %255[^\n]", key, value) != 2)
+            continue;
+
+        if (strcmp(key, "log_level") == 0)
+            strncpy(config->log_level, value, sizeof(config->log_level) - 1);
+    }
+
+    fclose(fp);
+
+    return 0;
+}
+{{- end}}
+{{- if .LogFile}}
+
+/*
+ * Redirects the log messages, written to the standard error, into a file. It
+ * is opened in append mode, so it may be truncated by logrotate.
+ */
+static int log_file_open(const char *filename)
+{
+    int fd;
+
+    fd = open(filename, O_WRONLY | O_CREAT | O_APPEND, 0640);
+
+    if (fd < 0) {
+        fprintf(stderr, "%s: could not open '%s'\n", APP_NAME, filename);
+        return -1;
+    }
+
+    dup2(fd, STDERR_FILENO);
+    close(fd);
+
+    return 0;
+}
+{{- end}}
+{{- if .ForkingService}}
+
+/*
+ * Runs in the background, as systemd expects from forking services, and
+ * writes the PID file of the service unit. The standard output and error are
+ * kept, since they may go to the journal or to the log file.
+ */
+static int service_daemonize(void)
+{
+    FILE *fp;
+
+    if (daemon(0, 1) < 0) {
+        fprintf(stderr, "%s: could not run in background\n", APP_NAME);
+        return -1;
+    }
+
+    fp = fopen("/run/{{.ProjectName}}/{{.ProjectName}}.pid", "w");
+
+    if (NULL == fp) {
+        fprintf(stderr, "%s: could not write the PID file\n", APP_NAME);
+        return -1;
+    }
+
+    fprintf(fp, "%d\n", getpid());
+    fclose(fp);
+
+    return 0;
+}
+{{- end}}
+{{- if .NotifyService}}
+
+/*
+ * Tells systemd the service is ready, through the socket from NOTIFY_SOCKET,
+ * as sd_notify(3) does. Nothing is done when not started by systemd.
+ */
+static void service_notify_ready(void)
+{
+    const char *path = getenv("NOTIFY_SOCKET");
+    const char *state = "READY=1";
+    struct sockaddr_un addr = {
+        .sun_family = AF_UNIX,
+    };
+    int fd;
+
+    if ((NULL == path) || ((path[0] != '/') && (path[0] != '@')) ||
+        (strlen(path) >= sizeof(addr.sun_path)))
+        return;
+
+    fd = socket(AF_UNIX, SOCK_DGRAM | SOCK_CLOEXEC, 0);
+
+    if (fd < 0)
+        return;
+
+    memcpy(addr.sun_path, path, strlen(path));
+
+    /* An abstract socket address */
+    if (addr.sun_path[0] == '@')
+        addr.sun_path[0] = '\0';
+
+    sendto(fd, state, strlen(state), 0, (struct sockaddr *)&addr,
+           offsetof(struct sockaddr_un, sun_path) + strlen(path));
+
+    close(fd);
+}
+{{- end}}
+{{- if .SocketActivated}}
+
+/*
+ * Gives the listening socket passed by the systemd socket unit, as
+ * sd_listen_fds(3) does, or -1 when the service was not socket activated.
+ */
+static int service_listen_fd(void)
+{
+    const char *pid = getenv("LISTEN_PID");
+    const char *fds = getenv("LISTEN_FDS");
+
+    if ((NULL == pid) || (NULL == fds) || (atoi(pid) != getpid()) ||
+        (atoi(fds) < 1))
+        return -1;
+
+    /* Passed sockets start right after the standard error */
+    return STDERR_FILENO + 1;
+}
+{{- end}}
+
 int main(int argc, char **argv)
 {
+{{- if or .ConfigFile .LogFile}}
+	const char *opt = "hv{{if .ConfigFile}}c:{{end}}{{if .LogFile}}l:{{end}}\0";
+	struct option long_options[] = {
+{{- if .ConfigFile}}
+		{ "config", required_argument, NULL, 'c' },
+{{- end}}
+{{- if .LogFile}}
+		{ "log-file", required_argument, NULL, 'l' },
+{{- end}}
+		{ NULL, 0, NULL, 0 }
+	};
+{{- if .ConfigFile}}
+	struct {{.ProjectName}}_config config = {
+		.log_level = "info",
+	};
+{{- end}}
+{{- else}}
 	const char *opt = "hv\0";
+{{- end}}
 	int option;
+{{- if .SocketActivated}}
+	int listen_fd;
+{{- end}}
 
 	do {
+{{- if or .ConfigFile .LogFile}}
+		option = getopt_long(argc, argv, opt, long_options, NULL);
+{{- else}}
 		option = getopt(argc, argv, opt);
+{{- end}}
 
 		switch (option) {
 			case 'h':
@@ -114,11 +300,48 @@ int main(int argc, char **argv)
 			case 'v':
 				version();
 				return 1;
+{{- if .ConfigFile}}
+
+			case 'c':
+				if (config_load(optarg, &config) < 0)
+					return -1;
+
+				break;
+{{- end}}
+{{- if .LogFile}}
+
+			case 'l':
+				if (log_file_open(optarg) < 0)
+					return -1;
+
+				break;
+{{- end}}
 
 			case '?':
 				return -1;
 		}
 	} while (option != -1);
+{{- if .ForkingService}}
+
+	if (service_daemonize() < 0)
+		return -1;
+{{- end}}
+{{- if .SocketActivated}}
+
+	listen_fd = service_listen_fd();
+
+	/*
+	 * TODO: Accept the client connections from listen_fd, or bind the service
+	 * address when not started by systemd.
+	 */
+	if (listen_fd < 0)
+		fprintf(stderr, "%s: not socket activated\n", APP_NAME);
+{{- end}}
+{{- if .NotifyService}}
+
+	/* TODO: Only tell systemd after everything is initialized. */
+	service_notify_ready();
+{{- end}}
 
 	return 0;
 }
@@ -276,24 +499,34 @@ func main() {
 	}
 }
 
-func NewSource(options base.FileOptions) base.FileTemplate {
+// sourceContent gives the template of a source file content based on its name
+// (basename).
+func sourceContent(bname string, options base.FileOptions) string {
 	var content string
-	bname, _ := extractFilename(options.Name, options.ProjectType)
-	contentData := GetContentData(options)
 
-	// here we build what will be the file content based on its name (basename)
 	if bname == "main" {
 		content = mainContent
 	} else if bname == "error" {
 		content = errorContent(Source, options)
+	} else if bname == "utils" && options.ProjectType == base.LibraryProject {
+		content = utilsContent
 	} else if bname == "plugin" {
 		content = pluginContent(options)
+	} else if strings.HasPrefix(bname, "test_") {
+		content = testContent(bname, options)
 	}
 
+	return content
+}
+
+func NewSource(options base.FileOptions) base.FileTemplate {
+	bname, _ := extractFilename(options.Name, options.ProjectType)
+	contentData := GetContentData(options)
+
 	return &SourceFile{
 		options:     options,
 		filename:    bname,
-		content:     content,
+		content:     sourceContent(bname, options),
 		ContentData: contentData,
 	}
 }
@@ -0,0 +1,146 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/template"
+
+	"source-template/pkg/base"
+)
+
+const editorConfigContent = `root = true
+
+[*]
+charset = utf-8
+end_of_line = lf
+insert_final_newline = true
+trim_trailing_whitespace = true
+
+[*.{c,h}]
+indent_style = {{.IndentStyle}}
+indent_size = {{.IndentSize}}
+
+[{CMakeLists.txt,*.cmake,meson.build,meson_options.txt}]
+indent_style = space
+indent_size = 4
+
+[Makefile]
+indent_style = tab
+`
+
+// The compilation database is expected at the project root, where cmake and
+// meson link the one from their build directory.
+const clangdContent = `CompileFlags:
+  CompilationDatabase: .
+`
+
+// Make does not create a compilation database, so it must be generated by
+// another tool, which also writes it at the project root.
+const clangdMakeContent = `# Create compile_commands.json by building the project with 'bear -- make'
+# (or 'compiledb make').
+CompileFlags:
+  CompilationDatabase: .
+`
+
+// StyleFile holds the editor and tooling configuration files which describe
+// the project code style.
+type StyleFile struct {
+	content string
+	base.FileOptions
+}
+
+func (s StyleFile) Header(file *os.File) {
+}
+
+func (s StyleFile) HeaderComment(file *os.File) {
+}
+
+func (s StyleFile) Footer(file *os.File) {
+}
+
+func (s StyleFile) Content(file *os.File) {
+	file.WriteString(s.content)
+}
+
+func clangFormatContent(style int) string {
+	var s bytes.Buffer
+
+	s.WriteString("---\nLanguage: Cpp\n")
+
+	for _, option := range base.StyleOptions(style) {
+		s.WriteString(fmt.Sprintf("%s: %s\n", option.Key, option.Value))
+	}
+
+	return s.String()
+}
+
+func editorConfig(style int) string {
+	var s bytes.Buffer
+	indent := base.StyleIndent(style)
+	data := struct {
+		IndentStyle string
+		IndentSize  int
+	}{
+		IndentStyle: "space",
+		IndentSize:  len(indent),
+	}
+
+	if indent == "\t" {
+		data.IndentStyle = "tab"
+		data.IndentSize = 8
+	}
+
+	tpl, err := template.New("editorconfig").Parse(editorConfigContent)
+
+	if err != nil {
+		return ""
+	}
+
+	tpl.Execute(&s, data)
+
+	return s.String()
+}
+
+// NewStyle creates a code style configuration file template. It may be the
+// .clang-format, .editorconfig or .clangd file, depending on the file name.
+func NewStyle(options base.FileOptions) base.FileTemplate {
+	var content string
+
+	switch options.Name {
+	case ".clang-format":
+		content = clangFormatContent(options.Style)
+
+	case ".editorconfig":
+		content = editorConfig(options.Style)
+
+	case ".clangd":
+		if options.BuildSystem == base.MakeBuildSystem {
+			content = clangdMakeContent
+		} else {
+			content = clangdContent
+		}
+	}
+
+	return &StyleFile{
+		FileOptions: options,
+		content:     content,
+	}
+}
@@ -18,25 +18,74 @@
 package templates
 
 import (
+	"bytes"
 	"os"
+	"regexp"
 	"text/template"
 
 	"source-template/pkg/base"
 )
 
 type SymbolFile struct {
+	symbols []string
 	base.FileOptions
 	ContentData
 }
 
+// The library version script. Only the public API is exported, everything
+// else is kept hidden by the local catch-all.
 const content = `LIB{{.ProjectNameUpper}}_0.1 {
+{{- if .Symbols}}
 	global:
-		*;
+{{- range .Symbols}}
+		{{.}};
+{{- end}}
+{{- end}}
 	local:
 		*;
 };
 `
 
+// pubAPIFunction matches a function declared with the __PUB_API__ mark,
+// capturing its name.
+var pubAPIFunction = regexp.MustCompile(`__PUB_API__[^(;{]*?(\w+)\s*\(`)
+
+// PublicSymbols gives the name of all functions marked as __PUB_API__ inside
+// a C source content.
+func PublicSymbols(source string) []string {
+	var symbols []string
+
+	for _, match := range pubAPIFunction.FindAllStringSubmatch(source, -1) {
+		symbols = append(symbols, match[1])
+	}
+
+	return symbols
+}
+
+// librarySymbols gives the public symbols of all library sources, by looking
+// at the content they will have.
+func librarySymbols(options base.FileOptions, sources []string) []string {
+	var symbols []string
+	contentData := GetContentData(options)
+
+	for _, source := range sources {
+		var cnt bytes.Buffer
+		tpl, err := template.New("source").Parse(sourceContent(source, options))
+
+		if err != nil {
+			continue
+		}
+
+		if err := tpl.Execute(&cnt, contentData); err != nil {
+			continue
+		}
+
+		symbols = append(symbols, PublicSymbols(cnt.String())...)
+	}
+
+	return symbols
+}
+
 func (s SymbolFile) Header(file *os.File) {
 }
 
@@ -54,12 +103,21 @@ func (s SymbolFile) Content(file *os.File) {
 		return
 	}
 
-	tpl.Execute(file, s.ContentData)
+	tpl.Execute(file, struct {
+		ContentData
+		Symbols []string
+	}{
+		ContentData: s.ContentData,
+		Symbols:     s.symbols,
+	})
 }
 
-func NewSymbol(options base.FileOptions) base.FileTemplate {
+// NewSymbol creates the library version script template. It also receives
+// the library source file names, to export the public API declared in them.
+func NewSymbol(options base.FileOptions, sources []string) base.FileTemplate {
 	return &SymbolFile{
 		FileOptions: options,
+		symbols:     librarySymbols(options, sources),
 		ContentData: GetContentData(options),
 	}
 }
@@ -0,0 +1,296 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package templates
+
+import (
+	"os"
+	"strings"
+	"text/template"
+
+	"source-template/pkg/base"
+)
+
+const serviceContent = `[Unit]
+Description=The {{.ProjectName}} {{.ProjectTypeName}}
+After=network.target
+{{- if .Socket}}
+Requires={{.ProjectName}}.socket
+{{- end}}
+
+[Service]
+{{- if .Timer}}
+Type=oneshot
+{{- else}}
+Type={{.Type}}
+{{- end}}
+{{- if eq .Type "forking"}}
+PIDFile=/run/{{.ProjectName}}/{{.ProjectName}}.pid
+RuntimeDirectory={{.ProjectName}}
+{{- end}}
+{{- if .User}}
+User={{.User}}
+Group={{.User}}
+{{- end}}
+{{- if .EnvironmentFile}}
+EnvironmentFile=-/etc/default/{{.ProjectName}}
+{{- end}}
+StateDirectory={{.ProjectName}}
+{{- if .LogFile}}
+LogsDirectory={{.ProjectName}}
+{{- end}}
+WorkingDirectory=/var/lib/{{.ProjectName}}
+ExecStart={{.ExecStart}}{{if .Arguments}} {{.Arguments}}{{end}}
+{{- if not .Timer}}
+Restart=on-failure
+RestartSec=1
+{{- end}}
+{{- if .Hardening}}
+
+# Hardening
+NoNewPrivileges=yes
+ProtectSystem=strict
+ProtectHome=yes
+PrivateTmp=yes
+PrivateDevices=yes
+ProtectKernelTunables=yes
+ProtectKernelModules=yes
+ProtectControlGroups=yes
+RestrictSUIDSGID=yes
+RestrictRealtime=yes
+LockPersonality=yes
+{{- end}}
+{{- if not .Timer}}
+
+[Install]
+WantedBy=multi-user.target
+{{- if .Socket}}
+Also={{.ProjectName}}.socket
+{{- end}}
+{{- end}}
+`
+
+const socketContent = `[Unit]
+Description=The {{.ProjectName}} socket
+
+[Socket]
+ListenStream={{.Socket}}
+
+[Install]
+WantedBy=sockets.target
+`
+
+const timerContent = `[Unit]
+Description=Runs {{.ProjectName}} periodically
+
+[Timer]
+OnCalendar={{.Timer}}
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`
+
+const openrcContent = `#!/sbin/openrc-run
+
+description="The {{.ProjectName}} {{.ProjectTypeName}}"
+command="{{.ExecStart}}"
+{{- if .Arguments}}
+command_args="{{.Arguments}}"
+{{- end}}
+{{- if eq .Type "forking"}}
+pidfile="/run/{{.ProjectName}}/{{.ProjectName}}.pid"
+{{- else}}
+command_background=true
+pidfile="/run/${RC_SVCNAME}.pid"
+{{- end}}
+{{- if .User}}
+command_user="{{.User}}:{{.User}}"
+{{- end}}
+directory="/var/lib/{{.ProjectName}}"
+
+depend() {
+	need net
+}
+
+start_pre() {
+	checkpath -d -m 0755{{if .User}} -o {{.User}}:{{.User}}{{end}} /var/lib/{{.ProjectName}}
+{{- if .LogFile}}
+	checkpath -d -m 0750{{if .User}} -o {{.User}}:{{.User}}{{end}} /var/log/{{.ProjectName}}
+{{- end}}
+{{- if eq .Type "forking"}}
+	checkpath -d -m 0755{{if .User}} -o {{.User}}:{{.User}}{{end}} /run/{{.ProjectName}}
+{{- end}}
+}
+`
+
+// UnitData holds what is needed to describe a project as systemd units.
+type UnitData struct {
+	ContentData
+	ExecStart       string
+	Arguments       string
+	Type            string
+	User            string
+	Hardening       bool
+	EnvironmentFile bool
+	Socket          string
+	Timer           string
+}
+
+type UnitFile struct {
+	content string
+	base.FileOptions
+	UnitData
+}
+
+func (u UnitFile) Header(file *os.File) {
+}
+
+func (u UnitFile) HeaderComment(file *os.File) {
+}
+
+func (u UnitFile) Footer(file *os.File) {
+}
+
+func (u UnitFile) Content(file *os.File) {
+	tpl, err := template.New("unit").Parse(u.content)
+
+	if err != nil {
+		return
+	}
+
+	tpl.Execute(file, u.UnitData)
+}
+
+// SystemdUnits gives the name of all systemd unit files of a project: its
+// service, along with the optional socket and timer units.
+func SystemdUnits(options base.ProjectOptions) []string {
+	var units []string
+	name := strings.ToLower(options.ProjectName)
+
+	if !options.HasService() {
+		return units
+	}
+
+	units = append(units, name+".service")
+
+	if options.ServiceSocket != "" {
+		units = append(units, name+".socket")
+	}
+
+	if options.ServiceTimer != "" {
+		units = append(units, name+".timer")
+	}
+
+	return units
+}
+
+// enabledUnits gives the units that must be enabled, which are the ones
+// with an [Install] section. Timer activated services have none.
+func enabledUnits(options base.ProjectOptions) []string {
+	var units []string
+
+	for _, u := range SystemdUnits(options) {
+		if strings.HasSuffix(u, ".service") && options.ServiceTimer != "" {
+			continue
+		}
+
+		units = append(units, u)
+	}
+
+	return units
+}
+
+// startedUnits gives the units that must be started after installed. Socket
+// activated services are started by their socket.
+func startedUnits(options base.ProjectOptions) []string {
+	var units []string
+
+	for _, u := range enabledUnits(options) {
+		if strings.HasSuffix(u, ".service") && options.ServiceSocket != "" {
+			continue
+		}
+
+		units = append(units, u)
+	}
+
+	return units
+}
+
+// unitExecStart gives where the project executable is installed.
+func unitExecStart(options base.FileOptions) string {
+	// Scripts are always installed with the system binaries
+	if options.ProjectType == base.ScriptProject {
+		return "/usr/bin/" + options.ProjectName
+	}
+
+	return options.InstallPrefix + "/bin/" + options.ProjectName
+}
+
+// unitArguments gives the command line arguments the service passes to the
+// project executable.
+func unitArguments(options base.FileOptions) string {
+	var arguments []string
+
+	if config := options.ConfigFile(); config != "" {
+		arguments = append(arguments, "--config", config)
+	}
+
+	if logFile := options.LogFile(); logFile != "" {
+		arguments = append(arguments, "--log-file", logFile)
+	}
+
+	return strings.Join(arguments, " ")
+}
+
+// NewUnit creates a systemd unit template, which may be the project service
+// or its .socket and .timer companions. The OpenRC init script (.initd) of
+// the service is also created here.
+func NewUnit(options base.FileOptions) base.FileTemplate {
+	var content string
+	_, extension := extractFilename(options.Name, options.ProjectType)
+
+	switch extension {
+	case ".service":
+		content = serviceContent
+
+	case ".socket":
+		content = socketContent
+
+	case ".timer":
+		content = timerContent
+
+	case ".initd":
+		content = openrcContent
+	}
+
+	return &UnitFile{
+		FileOptions: options,
+		content:     content,
+		UnitData: UnitData{
+			ContentData:     GetContentData(options),
+			ExecStart:       unitExecStart(options),
+			Arguments:       unitArguments(options),
+			Type:            options.ServiceType,
+			User:            options.ServiceUser,
+			Hardening:       options.ServiceHardening,
+			EnvironmentFile: options.ServiceEnvironment,
+			Socket:          options.ServiceSocket,
+			Timer:           options.ServiceTimer,
+		},
+	}
+}
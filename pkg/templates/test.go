@@ -0,0 +1,229 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package templates
+
+import (
+	"strings"
+
+	"source-template/pkg/base"
+)
+
+// testAssertHeaderContent is a minimal subset of the cmocka API, so the
+// generated tests do not depend on anything and may be moved to cmocka later
+// without changes.
+const testAssertHeaderContent = `
+/*
+ * A minimal subset of the cmocka API. Tests written with it may be built
+ * against cmocka by only replacing this header.
+ */
+
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <setjmp.h>
+
+struct CMUnitTest {
+    const char *name;
+    void (*test_func)(void **state);
+};
+
+static jmp_buf ptpl_env;
+
+#define cmocka_unit_test(f)             { #f, f }
+
+#define cmocka_run_group_tests(tests, setup, teardown)                  \
+    ptpl_run_tests(tests, sizeof(tests) / sizeof((tests)[0]))
+
+#define ptpl_fail(fmt, ...)                                             \
+    do {                                                                \
+        fprintf(stderr, "%s:%d: " fmt "\n", __FILE__, __LINE__,         \
+                __VA_ARGS__);                                           \
+        longjmp(ptpl_env, 1);                                           \
+    } while (0)
+
+#define skip()                          longjmp(ptpl_env, 2)
+
+#define assert_true(c)                                                  \
+    do {                                                                \
+        if (!(c))                                                       \
+            ptpl_fail("'%s' is not true", #c);                          \
+    } while (0)
+
+#define assert_false(c)                                                 \
+    do {                                                                \
+        if (c)                                                          \
+            ptpl_fail("'%s' is not false", #c);                         \
+    } while (0)
+
+#define assert_null(c)                  assert_true((c) == NULL)
+#define assert_non_null(c)              assert_true((c) != NULL)
+
+#define assert_int_equal(a, b)                                          \
+    do {                                                                \
+        long long ptpl_a = (a), ptpl_b = (b);                           \
+                                                                        \
+        if (ptpl_a != ptpl_b)                                           \
+            ptpl_fail("%lld != %lld", ptpl_a, ptpl_b);                  \
+    } while (0)
+
+#define assert_string_equal(a, b)                                       \
+    do {                                                                \
+        const char *ptpl_a = (a), *ptpl_b = (b);                        \
+                                                                        \
+        if (strcmp(ptpl_a, ptpl_b) != 0)                                \
+            ptpl_fail("\"%s\" != \"%s\"", ptpl_a, ptpl_b);              \
+    } while (0)
+
+static int ptpl_run_tests(const struct CMUnitTest *tests, size_t count)
+{
+    size_t i;
+    int failed = 0, skipped = 0;
+
+    for (i = 0; i < count; i++) {
+        switch (setjmp(ptpl_env)) {
+        case 0:
+            tests[i].test_func(NULL);
+            printf("[       OK ] %s\n", tests[i].name);
+            break;
+
+        case 2:
+            printf("[  SKIPPED ] %s\n", tests[i].name);
+            skipped++;
+            break;
+
+        default:
+            printf("[  FAILED  ] %s\n", tests[i].name);
+            failed++;
+            break;
+        }
+    }
+
+    printf("%zu test(s) run, %d failed, %d skipped\n", count, failed, skipped);
+
+    return failed;
+}
+`
+
+const testBuiltinIncludes = `
+#include "test_assert.h"
+`
+
+const testCmockaIncludes = `
+#include <stdarg.h>
+#include <stddef.h>
+#include <setjmp.h>
+#include <stdint.h>
+#include <cmocka.h>
+`
+
+const testLibraryErrorContent = `
+static void test_strerror_known_code(void **state)
+{
+    (void)state;
+    assert_string_equal({{.ProjectName}}_strerror({{.ProjectNameUpper}}_NO_ERROR), "Ok");
+}
+
+static void test_strerror_unknown_code(void **state)
+{
+    (void)state;
+    assert_string_equal({{.ProjectName}}_strerror({{.ProjectNameUpper}}_MAX_ERROR_CODE),
+                        "Unknown error");
+}
+
+int main(void)
+{
+    const struct CMUnitTest tests[] = {
+        cmocka_unit_test(test_strerror_known_code),
+        cmocka_unit_test(test_strerror_unknown_code),
+    };
+
+    return cmocka_run_group_tests(tests, NULL, NULL);
+}
+`
+
+const testLibraryUtilsContent = `
+#include <stdio.h>
+
+static void test_version(void **state)
+{
+    char version[32];
+
+    (void)state;
+    snprintf(version, sizeof(version), "%d.%d.%d", MAJOR_VERSION, MINOR_VERSION,
+             RELEASE);
+
+    assert_string_equal({{.ProjectName}}_version(), version);
+}
+
+int main(void)
+{
+    const struct CMUnitTest tests[] = {
+        cmocka_unit_test(test_version),
+    };
+
+    return cmocka_run_group_tests(tests, NULL, NULL);
+}
+`
+
+const testApplicationContent = `
+static void test_application_name(void **state)
+{
+    (void)state;
+    assert_string_equal(APP_NAME, "{{.ProjectName}}");
+}
+
+static void test_application_version(void **state)
+{
+    (void)state;
+    assert_true(MAJOR_VERSION > 0 || MINOR_VERSION > 0 || RELEASE > 0);
+}
+
+int main(void)
+{
+    const struct CMUnitTest tests[] = {
+        cmocka_unit_test(test_application_name),
+        cmocka_unit_test(test_application_version),
+    };
+
+    return cmocka_run_group_tests(tests, NULL, NULL);
+}
+`
+
+// testContent gives the content of a unit test source file. Its name must be
+// in the form test_<module>, where module is the source being tested.
+func testContent(bname string, options base.FileOptions) string {
+	var content string
+	module := strings.TrimPrefix(bname, "test_")
+
+	if options.CmockaTests {
+		content = testCmockaIncludes
+	} else {
+		content = testBuiltinIncludes
+	}
+
+	// Libraries have only the utils and error modules
+	if options.ProjectType == base.LibraryProject {
+		if module == "error" {
+			return content + testLibraryErrorContent
+		}
+
+		return content + testLibraryUtilsContent
+	}
+
+	return content + testApplicationContent
+}
@@ -19,26 +19,48 @@ package templates
 
 import (
 	"os"
+	"strings"
 	"text/template"
 
 	"source-template/pkg/base"
 )
 
-const serviceContent = `
-[Unit]
-Description=
-After=
-
-[Service]
-Type=simple
-User=root
-WorkingDirectory=
-ExecStart=
-Restart=always
-RestartSec=1
-
-[Install]
-WantedBy=multi-user.target
+const pkgConfigContent = `prefix=@prefix@
+exec_prefix=${prefix}
+libdir=@libdir@
+includedir=@includedir@
+
+Name: lib{{.ProjectName}}
+Description: The {{.ProjectName}} library
+Version: @version@
+{{- if .LibcollectionsLinker}}
+Requires.private: {{.LibcollectionsLinker}}
+{{- end}}
+Libs: -L${libdir} -l{{.ProjectName}}
+Cflags: -I${includedir}/{{.ProjectName}}
+`
+
+const configContent = `# {{.ProjectName}} configuration, loaded with '{{.ProjectName}} --config <file>'.
+#
+# Each setting is a 'key = value' line, and lines starting with '#' are
+# ignored.
+
+# Messages level (error, warning, info or debug)
+log_level = info
+`
+
+const logrotateContent = `/var/log/{{.ProjectName}}/*.log {
+{{- if .ServiceUserName}}
+    su {{.ServiceUserName}} {{.ServiceUserName}}
+{{- end}}
+    weekly
+    rotate 4
+    compress
+    delaycompress
+    missingok
+    notifempty
+    copytruncate
+}
 `
 
 type TextFile struct {
@@ -69,17 +91,23 @@ func (s TextFile) Content(file *os.File) {
 
 func NewText(options base.FileOptions) base.FileTemplate {
 	var content string
-	_, extension := extractFilename(options.Name, options.ProjectType)
+	contentData := GetContentData(options)
+
+	if strings.HasSuffix(options.Name, ".pc.in") {
+		content = pkgConfigContent
 
-	if options.PackageProject {
-		if extension == ".service" {
-			content = serviceContent
+		if options.LibcollectionsFeatures {
+			contentData.LibcollectionsLinker = "collections"
 		}
+	} else if strings.HasSuffix(options.Name, ".conf") {
+		content = configContent
+	} else if strings.HasSuffix(options.Name, ".logrotate") {
+		content = logrotateContent
 	}
 
 	return &TextFile{
 		FileOptions: options,
 		content:     content,
-		ContentData: GetContentData(options),
+		ContentData: contentData,
 	}
 }
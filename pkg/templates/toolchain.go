@@ -0,0 +1,168 @@
+//
+// Copyright (C) 2017 Rodrigo Freitas
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+package templates
+
+import (
+	"os"
+	"sort"
+	"text/template"
+
+	"source-template/pkg/base"
+)
+
+const cmakeToolchainContent = `# Cross-compilation toolchain for the {{.Name}} architecture.
+#
+# Usage (from the build-{{.Name}} directory):
+#   cmake -DCMAKE_TOOLCHAIN_FILE=../cmake/toolchains/{{.Name}}.cmake ..
+#
+# A target root filesystem may be used through the SYSROOT environment
+# variable.
+
+set(CMAKE_SYSTEM_NAME Linux)
+set(CMAKE_SYSTEM_PROCESSOR {{.Processor}})
+
+set(CROSS_TRIPLET {{.Triplet}})
+set(CMAKE_C_COMPILER ${CROSS_TRIPLET}-gcc)
+set(CMAKE_AR ${CROSS_TRIPLET}-ar CACHE FILEPATH "Archiver")
+set(CMAKE_STRIP ${CROSS_TRIPLET}-strip CACHE FILEPATH "Strip")
+
+if(DEFINED ENV{SYSROOT})
+    set(CMAKE_SYSROOT $ENV{SYSROOT})
+    set(CMAKE_FIND_ROOT_PATH $ENV{SYSROOT})
+else()
+    set(CMAKE_FIND_ROOT_PATH /usr/${CROSS_TRIPLET} /usr/lib/${CROSS_TRIPLET})
+endif()
+
+set(CMAKE_FIND_ROOT_PATH_MODE_PROGRAM NEVER)
+set(CMAKE_FIND_ROOT_PATH_MODE_LIBRARY ONLY)
+set(CMAKE_FIND_ROOT_PATH_MODE_INCLUDE ONLY)
+set(CMAKE_FIND_ROOT_PATH_MODE_PACKAGE ONLY)
+
+set(ENV{PKG_CONFIG_LIBDIR} /usr/lib/${CROSS_TRIPLET}/pkgconfig)
+`
+
+const mesonCrossContent = `# Cross-compilation file for the {{.Name}} architecture.
+#
+# Usage: meson setup build-{{.Name}} --cross-file cross/{{.Name}}.ini
+
+[binaries]
+c = '{{.Triplet}}-gcc'
+ar = '{{.Triplet}}-ar'
+strip = '{{.Triplet}}-strip'
+pkg-config = '{{.Triplet}}-pkg-config'
+
+[host_machine]
+system = 'linux'
+cpu_family = '{{.CPUFamily}}'
+cpu = '{{.CPU}}'
+endian = 'little'
+`
+
+// Architecture describes a target architecture, named as debian does, to
+// which projects may be cross compiled.
+type Architecture struct {
+	Name      string
+	Processor string
+	Triplet   string
+	CPUFamily string
+	CPU       string
+}
+
+var crossArchitectures = map[string]Architecture{
+	"armhf": {
+		Name:      "armhf",
+		Processor: "arm",
+		Triplet:   "arm-linux-gnueabihf",
+		CPUFamily: "arm",
+		CPU:       "armv7hl",
+	},
+	"arm64": {
+		Name:      "arm64",
+		Processor: "aarch64",
+		Triplet:   "aarch64-linux-gnu",
+		CPUFamily: "aarch64",
+		CPU:       "aarch64",
+	},
+	"riscv64": {
+		Name:      "riscv64",
+		Processor: "riscv64",
+		Triplet:   "riscv64-linux-gnu",
+		CPUFamily: "riscv64",
+		CPU:       "riscv64",
+	},
+}
+
+// CrossArchitectures gives the name of all architectures that have a
+// toolchain file.
+func CrossArchitectures() []string {
+	var names []string
+
+	for name := range crossArchitectures {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+type ToolchainFile struct {
+	content string
+	base.FileOptions
+	Architecture
+}
+
+func (t ToolchainFile) Header(file *os.File) {
+}
+
+func (t ToolchainFile) HeaderComment(file *os.File) {
+}
+
+func (t ToolchainFile) Footer(file *os.File) {
+}
+
+func (t ToolchainFile) Content(file *os.File) {
+	tpl, err := template.New("toolchain").Parse(t.content)
+
+	if err != nil {
+		return
+	}
+
+	tpl.Execute(file, t.Architecture)
+}
+
+// NewToolchain creates a cross-compilation file template, a CMake toolchain
+// file or a meson cross file, for the architecture that names the file.
+func NewToolchain(options base.FileOptions) base.FileTemplate {
+	var content string
+	bname, extension := extractFilename(options.Name, options.ProjectType)
+
+	switch extension {
+	case ".cmake":
+		content = cmakeToolchainContent
+
+	case ".ini":
+		content = mesonCrossContent
+	}
+
+	return &ToolchainFile{
+		FileOptions:  options,
+		content:      content,
+		Architecture: crossArchitectures[bname],
+	}
+}